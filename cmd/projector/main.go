@@ -0,0 +1,58 @@
+// Command projector maintains the read models (products by id, products by
+// price and category product counts) from the events the relay publishes.
+// Run it with -rebuild to clear the read models and replay every retained
+// event.
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/helpers"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/worker"
+)
+
+func main() {
+	var configOpts helpers.ConfigOptions
+	configOpts.RegisterFlags(flag.CommandLine)
+	rebuild := flag.Bool("rebuild", false, "clear the read models and rebuild them from the earliest retained event")
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	process, err := helpers.Bootstrap(ctx, &configOpts, (*pkg.Config).ValidateProjector, "products-projector")
+	if err != nil {
+		slog.Error("failed to start projection consumer", "error", err)
+		os.Exit(1)
+	}
+	defer process.Close()
+
+	projectionWorker, err := worker.NewProjectionWorker(ctx, process.Config, process.Secrets, process.Store, *rebuild)
+	if err != nil {
+		slog.Error("failed to create projection consumer", "error", err)
+		os.Exit(1)
+	}
+	defer projectionWorker.Close()
+
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// SIGHUP and new SSM parameter versions reload the log level
+	process.Watch(runCtx)
+	process.ServeMetrics(runCtx)
+
+	slog.Info("Starting projection consumer", "rebuild", *rebuild)
+	if err := projectionWorker.Run(runCtx); err != nil {
+		slog.Error("projection consumer stopped", "error", err)
+		projectionWorker.Close()
+		os.Exit(1)
+	}
+	slog.Info("Projection consumer has been stopped gracefully")
+}
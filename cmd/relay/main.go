@@ -0,0 +1,91 @@
+// Command relay runs the products outbox relay without the gRPC server, so
+// relay workers scale independently of API replicas. It competes for the
+// same lease as relays embedded in the server.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/helpers"
+	"github.com/yaninyzwitty/grpc-products-service/internal/metrics"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/worker"
+)
+
+const defaultHealthPort = 8081
+
+func main() {
+	var configOpts helpers.ConfigOptions
+	configOpts.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	process, err := helpers.Bootstrap(ctx, &configOpts, (*pkg.Config).ValidateRelay, "products-relay")
+	if err != nil {
+		slog.Error("failed to start outbox relay", "error", err)
+		os.Exit(1)
+	}
+	defer process.Close()
+	cfg := process.Config
+
+	relayWorker, err := worker.NewRelayWorker(ctx, cfg, process.Secrets, process.Store, nil)
+	if err != nil {
+		slog.Error("failed to create outbox relay", "error", err)
+		os.Exit(1)
+	}
+	defer relayWorker.Close()
+
+	healthPort := cfg.Outbox.HealthPort
+	if healthPort == 0 {
+		healthPort = defaultHealthPort
+	}
+	// Prometheus scrapes the relay on its health port
+	mux := http.NewServeMux()
+	mux.Handle("/", relayWorker.HealthHandler())
+	mux.Handle("GET /metrics", metrics.Handler())
+
+	healthServer := &http.Server{
+		Addr:              fmt.Sprintf(":%d", healthPort),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		slog.Info("Starting relay health endpoint", "port", healthPort)
+		if err := healthServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("relay health endpoint encountered an error while serving", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// SIGHUP and new SSM parameter versions reload the live settings
+	process.Reloader.OnReload(relayWorker.Reconfigure)
+	process.Watch(runCtx)
+
+	slog.Info("Starting outbox relay")
+	// Returns once a shutdown signal arrived and the lease was released
+	relayWorker.Run(runCtx)
+	slog.Info("Outbox relay stopped, shutting down health endpoint")
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelShutdown()
+
+	if err := healthServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to shut down health endpoint", "error", err)
+	}
+	slog.Info("Outbox relay has been stopped gracefully")
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/yaninyzwitty/grpc-products-service/helpers"
+)
+
+// runConfigCheck implements `config check`: it loads the config from the
+// same layers as the server and validates it without starting anything,
+// returning the exit code.
+func runConfigCheck(args []string) int {
+	fs := flag.NewFlagSet("config check", flag.ExitOnError)
+	var configOpts helpers.ConfigOptions
+	configOpts.RegisterFlags(fs)
+	_ = fs.Parse(args)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// A missing .env is fine, the overrides may come from the environment
+	_ = godotenv.Load()
+
+	cfg, err := helpers.LoadConfig(ctx, &configOpts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to load config:", err)
+		return 1
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "config is invalid:")
+		for problem := range strings.SplitSeq(err.Error(), "\n") {
+			fmt.Fprintln(os.Stderr, "  -", problem)
+		}
+		return 1
+	}
+
+	fmt.Println("config is valid")
+	return 0
+}
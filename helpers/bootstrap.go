@@ -0,0 +1,112 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/gocql/gocql"
+	"github.com/joho/godotenv"
+	"github.com/yaninyzwitty/grpc-products-service/internal/metrics"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/secrets"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+// Process holds what every command sets up before starting its own work:
+// the validated config, the secrets, tracing and the database session.
+type Process struct {
+	Config   *pkg.Config
+	Secrets  *secrets.Cache
+	Session  *gocql.Session
+	Reloader *pkg.Reloader
+	Store    *store.CassandraStore
+
+	opts            *ConfigOptions
+	shutdownTracing func()
+}
+
+// Bootstrap loads .env and the config layers, checks the config with
+// validate and sets up logging, secrets, tracing and the database session
+// for the named service. Close releases what it set up.
+func Bootstrap(ctx context.Context, opts *ConfigOptions, validate func(*pkg.Config) error, serviceName string) (*Process, error) {
+	var logLevel slog.LevelVar
+	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		Level: &logLevel,
+	})
+	slog.SetDefault(slog.New(logHandler))
+
+	// .env may hold tokens and PRODUCTS_* overrides, load it before the config
+	if err := godotenv.Load(); err != nil {
+		slog.Error("failed to load environment variables", "error", err)
+	}
+
+	cfg, err := LoadConfig(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	if err := validate(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	if err := SetupLogging(cfg, &logLevel); err != nil {
+		return nil, fmt.Errorf("invalid logging config: %w", err)
+	}
+
+	secretCache, err := NewSecrets(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create secret providers: %w", err)
+	}
+
+	shutdownTracing, err := SetupTracing(ctx, cfg, serviceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up tracing: %w", err)
+	}
+
+	driverCfg, err := DriverConfig(cfg)
+	if err != nil {
+		shutdownTracing()
+		return nil, fmt.Errorf("invalid database driver config: %w", err)
+	}
+
+	session, err := ConnectDatabase(ctx, cfg, driverCfg, secretCache)
+	if err != nil {
+		shutdownTracing()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	return &Process{
+		Config:   cfg,
+		Secrets:  secretCache,
+		Session:  session,
+		Reloader: NewReloader(cfg, opts, validate, &logLevel),
+		Store:    store.NewCassandraStore(session, driverCfg.Profiles),
+
+		opts:            opts,
+		shutdownTracing: shutdownTracing,
+	}, nil
+}
+
+// Watch reloads the config on SIGHUP and SSM parameter version changes and
+// refreshes the secrets so rotated values reach new connections, until ctx
+// is done. Register reload callbacks on Reloader before calling it.
+func (p *Process) Watch(ctx context.Context) {
+	go WatchConfig(ctx, p.opts, p.Reloader)
+	go p.Secrets.Run(ctx)
+}
+
+// ServeMetrics serves the Prometheus metrics on server.metrics_port until
+// ctx is done.
+func (p *Process) ServeMetrics(ctx context.Context) {
+	go func() {
+		if err := metrics.Serve(ctx, p.Config.Server.MetricsPort); err != nil {
+			slog.Error("metrics endpoint encountered an error while serving", "error", err)
+		}
+	}()
+}
+
+// Close closes the database session and flushes the pending spans.
+func (p *Process) Close() {
+	p.Session.Close()
+	p.shutdownTracing()
+}
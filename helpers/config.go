@@ -0,0 +1,116 @@
+package helpers
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+)
+
+// ConfigOptions selects the layers the config is loaded from.
+type ConfigOptions struct {
+	File         string
+	SSM          bool
+	SSMParameter string
+	// SSMPollInterval is how often the SSM parameter version is checked for
+	// a reload, zero disables it.
+	SSMPollInterval time.Duration
+}
+
+// RegisterFlags adds the --config, --ssm, --ssm-parameter and
+// --ssm-poll-interval flags to the set.
+func (o *ConfigOptions) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.File, "config", "", "path to a YAML config file")
+	fs.BoolVar(&o.SSM, "ssm", false, "load the config from AWS SSM Parameter Store")
+	fs.StringVar(&o.SSMParameter, "ssm-parameter", DefaultSSMParameter, "SSM parameter holding the YAML config")
+	fs.DurationVar(&o.SSMPollInterval, "ssm-poll-interval", time.Minute, "how often to check the SSM parameter for a new version to reload, 0 disables it")
+}
+
+// LoadConfig layers the defaults, the config file, SSM and finally the
+// PRODUCTS_* environment variables, so a laptop can run with a file and no
+// AWS account while deployments opt into SSM with --ssm.
+func LoadConfig(ctx context.Context, opts *ConfigOptions) (*pkg.Config, error) {
+	var sources []pkg.Source
+	if opts.File != "" {
+		sources = append(sources, pkg.FileSource{Path: opts.File})
+	}
+	if opts.SSM {
+		sources = append(sources, SSMSource{Parameter: opts.SSMParameter})
+	}
+	sources = append(sources, pkg.EnvSource{Prefix: pkg.EnvPrefix})
+
+	return pkg.Load(ctx, sources...)
+}
+
+// NewReloader returns a reloader loading from the same layers and checking
+// with the same validate as the process started with, keeping logLevel in
+// step with server.log_level.
+func NewReloader(cfg *pkg.Config, opts *ConfigOptions, validate func(*pkg.Config) error, logLevel *slog.LevelVar) *pkg.Reloader {
+	reloader := pkg.NewReloader(cfg, func(ctx context.Context) (*pkg.Config, error) {
+		return LoadConfig(ctx, opts)
+	}, validate)
+	reloader.OnReload(func(cfg *pkg.Config) {
+		if level, err := pkg.ParseLogLevel(cfg.Server.LogLevel); err == nil {
+			logLevel.Set(level)
+		}
+	})
+	return reloader
+}
+
+// WatchConfig reloads the config on SIGHUP and, when it is read from SSM,
+// whenever the parameter version changes, until ctx is done. Rejected
+// reloads are logged and leave the running config in place.
+func WatchConfig(ctx context.Context, opts *ConfigOptions, reloader *pkg.Reloader) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var (
+		source  = SSMSource{Parameter: opts.SSMParameter}
+		poll    <-chan time.Time
+		version int64
+	)
+	if opts.SSM && opts.SSMPollInterval > 0 {
+		ticker := time.NewTicker(opts.SSMPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+
+		v, err := source.Version(ctx)
+		if err != nil {
+			slog.Warn("failed to read config version", "parameter", opts.SSMParameter, "error", err)
+		}
+		version = v
+	}
+
+	reload := func(reason string) {
+		slog.Info("reloading config", "reason", reason)
+		if err := reloader.Reload(ctx); err != nil {
+			slog.Error("config reload rejected, keeping the running config", "error", err)
+		}
+	}
+
+	for {
+		select {
+		case <-hangup:
+			reload("SIGHUP")
+		case <-poll:
+			v, err := source.Version(ctx)
+			if err != nil {
+				slog.Warn("failed to read config version", "parameter", opts.SSMParameter, "error", err)
+				continue
+			}
+			if v == version {
+				continue
+			}
+			version = v
+			reload("ssm parameter version changed")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
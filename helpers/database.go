@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/internal/database"
+	"github.com/yaninyzwitty/grpc-products-service/internal/metrics"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/secrets"
+	"github.com/yaninyzwitty/grpc-products-service/internal/tracing"
+)
+
+// ConnectDatabase connects to Astra through its secure-connect bundle or to a
+// self-hosted Cassandra or ScyllaDB cluster, as selected by database.kind.
+func ConnectDatabase(ctx context.Context, cfg *pkg.Config, driver *database.DriverConfig, secretCache *secrets.Cache) (*gocql.Session, error) {
+	timeout := time.Duration(cfg.Database.ConnectTimeout) * time.Second
+
+	if cfg.Database.Kind != "cassandra" {
+		return database.NewAstraDB().Connect(ctx, &database.AstraConfig{
+			Username: cfg.Database.Username,
+			Path:     cfg.Database.Path,
+			Token:    secretCache.Supplier(secrets.AstraToken),
+			Driver:   driver,
+		}, timeout)
+	}
+
+	cassandraCfg := &database.CassandraConfig{
+		Hosts:    cfg.Database.Hosts,
+		Port:     cfg.Database.Port,
+		Username: cfg.Database.Username,
+		Password: secretCache.Supplier(secrets.CassandraPassword),
+		LocalDC:  cfg.Database.LocalDC,
+		Driver:   driver,
+	}
+	if tlsCfg := cfg.Database.TLS; tlsCfg.Enabled {
+		cassandraCfg.TLS = &database.TLSConfig{
+			CAFile:             tlsCfg.CAFile,
+			CertFile:           tlsCfg.CertFile,
+			KeyFile:            tlsCfg.KeyFile,
+			InsecureSkipVerify: tlsCfg.InsecureSkipVerify,
+		}
+	}
+	return database.NewCassandraDB().Connect(ctx, cassandraCfg, timeout)
+}
+
+// DriverConfig builds the driver settings and the per query type profiles
+// from the database config. Reads and scans are idempotent and may be
+// executed speculatively, lightweight transactions use the serial
+// consistency.
+func DriverConfig(cfg *pkg.Config) (*database.DriverConfig, error) {
+	db := cfg.Database
+
+	readConsistency, err := gocql.ParseConsistencyWrapper(db.ReadConsistency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database.read_consistency: %w", err)
+	}
+	writeConsistency, err := gocql.ParseConsistencyWrapper(db.WriteConsistency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database.write_consistency: %w", err)
+	}
+	var serialConsistency gocql.SerialConsistency
+	if err := serialConsistency.UnmarshalText([]byte(db.SerialConsistency)); err != nil {
+		return nil, fmt.Errorf("invalid database.serial_consistency: %w", err)
+	}
+
+	retries := retryPolicy(db.Retry)
+	speculative := speculativePolicy(db.Speculative)
+
+	profiles := database.QueryProfiles{
+		database.QueryRead: {
+			Consistency: readConsistency,
+			Idempotent:  true,
+			Speculative: speculative,
+		},
+		database.QueryScan: {
+			Consistency: readConsistency,
+			PageSize:    db.PageSize,
+			Idempotent:  true,
+			Speculative: speculative,
+		},
+		database.QueryWrite: {
+			Consistency: writeConsistency,
+		},
+		database.QueryLWT: {
+			Consistency:       writeConsistency,
+			SerialConsistency: serialConsistency,
+		},
+	}
+
+	for queryType, query := range db.Queries {
+		profile := profiles[database.QueryType(queryType)]
+		if query.Consistency != "" {
+			if profile.Consistency, err = gocql.ParseConsistencyWrapper(query.Consistency); err != nil {
+				return nil, fmt.Errorf("invalid database.queries.%s.consistency: %w", queryType, err)
+			}
+		}
+		if query.SerialConsistency != "" {
+			if err := profile.SerialConsistency.UnmarshalText([]byte(query.SerialConsistency)); err != nil {
+				return nil, fmt.Errorf("invalid database.queries.%s.serial_consistency: %w", queryType, err)
+			}
+		}
+		if query.PageSize > 0 {
+			profile.PageSize = query.PageSize
+		}
+		if query.Retry != nil {
+			profile.RetryPolicy = retryPolicy(*query.Retry)
+		}
+		if query.Speculative != nil {
+			profile.Speculative = speculativePolicy(*query.Speculative)
+		}
+		profiles[database.QueryType(queryType)] = profile
+	}
+
+	observers := database.Observers{metrics.CassandraObserver{}, tracing.CassandraObserver{}}
+
+	return &database.DriverConfig{
+		QueryTimeout:      time.Duration(db.Timeout) * time.Second,
+		NumConns:          db.NumConns,
+		Consistency:       writeConsistency,
+		SerialConsistency: serialConsistency,
+		PageSize:          db.PageSize,
+		RetryPolicy:       retries,
+		Profiles:          profiles,
+
+		QueryObserver: observers,
+		BatchObserver: observers,
+	}, nil
+}
+
+func retryPolicy(retry pkg.DBRetry) gocql.RetryPolicy {
+	return database.RetryPolicy(
+		retry.Attempts,
+		time.Duration(retry.MinBackoff)*time.Millisecond,
+		time.Duration(retry.MaxBackoff)*time.Millisecond,
+	)
+}
+
+func speculativePolicy(speculative pkg.DBSpeculative) gocql.SpeculativeExecutionPolicy {
+	return database.SpeculativePolicy(speculative.Attempts, time.Duration(speculative.Delay)*time.Millisecond)
+}
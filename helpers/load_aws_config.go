@@ -3,7 +3,6 @@ package helpers
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -14,49 +13,64 @@ import (
 	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
 )
 
-func FetchFromAWSConfig(ctx context.Context) (*pkg.Config, error) {
-	// Load AWS default config (from env, profile, IAM role, etc.)
-	cfg, err := config.LoadDefaultConfig(ctx)
+// DefaultSSMParameter is the SSM parameter holding the YAML config.
+const DefaultSSMParameter = "/myapp/config.yaml"
+
+// SSMSource reads the YAML config stored in an SSM parameter. Tokens are not
+// part of it, they are read through the secrets providers.
+type SSMSource struct {
+	Parameter string
+}
+
+func (s SSMSource) Name() string {
+	return "ssm " + s.Parameter
+}
+
+func (s SSMSource) Load(ctx context.Context, configData *pkg.Config) error {
+	ssmClient, err := newSSMClient(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+		return err
 	}
 
-	// Create AWS SSM client
-	ssmClient := ssm.NewFromConfig(cfg)
-
 	// Fetch YAML config from SSM Parameter Store
 	ssmParam, err := ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
-		Name:           aws.String("/myapp/config.yaml"), // match your stored key
+		Name:           aws.String(s.Parameter),
 		WithDecryption: aws.Bool(true),
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch config from AWS SSM: %v", err)
+		return fmt.Errorf("failed to fetch config from AWS SSM: %v", err)
 	}
 
-	configData := &pkg.Config{}
 	err = yaml.Unmarshal([]byte(strings.TrimSpace(*ssmParam.Parameter.Value)), configData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %v", err)
+		return fmt.Errorf("failed to parse YAML: %v", err)
 	}
 
-	// Fetch environment variables
-	envParams, err := ssmClient.GetParameters(ctx, &ssm.GetParametersInput{
-		Names:          []string{"/myapp/ASTRA_TOKEN", "/myapp/PULSAR_TOKEN"},
-		WithDecryption: aws.Bool(true),
-	})
+	return nil
+}
+
+// Version returns the current version of the config parameter, which SSM
+// bumps on every put.
+func (s SSMSource) Version(ctx context.Context) (int64, error) {
+	ssmClient, err := newSSMClient(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch environment variables: %v", err)
+		return 0, err
 	}
 
-	// Set environment variables in process
-	for _, p := range envParams.Parameters {
-		switch *p.Name {
-		case "/myapp/ASTRA_TOKEN":
-			_ = os.Setenv("ASTRA_TOKEN", *p.Value)
-		case "/myapp/PULSAR_TOKEN":
-			_ = os.Setenv("PULSAR_TOKEN", *p.Value)
-		}
+	ssmParam, err := ssmClient.GetParameter(ctx, &ssm.GetParameterInput{
+		Name: aws.String(s.Parameter),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch config version from AWS SSM: %v", err)
 	}
+	return ssmParam.Parameter.Version, nil
+}
 
-	return configData, nil
+func newSSMClient(ctx context.Context) (*ssm.Client, error) {
+	// Load AWS default config (from env, profile, IAM role, etc.)
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %v", err)
+	}
+	return ssm.NewFromConfig(cfg), nil
 }
@@ -0,0 +1,27 @@
+package helpers
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/logging"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+)
+
+// SetupLogging switches the default logger to the configured format and
+// level. The level stays adjustable through level, which config reloads
+// update.
+func SetupLogging(cfg *pkg.Config, level *slog.LevelVar) error {
+	parsed, err := pkg.ParseLogLevel(cfg.Server.LogLevel)
+	if err != nil {
+		return err
+	}
+	handler, err := logging.NewHandler(os.Stdout, cfg.Server.LogFormat, level)
+	if err != nil {
+		return err
+	}
+
+	level.Set(parsed)
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
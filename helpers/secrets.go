@@ -0,0 +1,22 @@
+package helpers
+
+import (
+	"context"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/secrets"
+)
+
+// NewSecrets builds the secret providers selected by the config.
+func NewSecrets(ctx context.Context, cfg *pkg.Config) (*secrets.Cache, error) {
+	return secrets.New(ctx, &secrets.Config{
+		Providers:     cfg.Secrets.Providers,
+		SSMPrefix:     cfg.Secrets.SSMPrefix,
+		Dir:           cfg.Secrets.Dir,
+		EncryptedFile: cfg.Secrets.EncryptedFile,
+
+		CacheTTL:         time.Duration(cfg.Secrets.CacheTTL) * time.Second,
+		RotationInterval: time.Duration(cfg.Secrets.RotationInterval) * time.Second,
+	})
+}
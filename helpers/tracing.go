@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/tracing"
+)
+
+// SetupTracing installs the trace exporter selected by the config, naming
+// the spans' service after the command. The returned function flushes the
+// pending spans and is meant to be deferred.
+func SetupTracing(ctx context.Context, cfg *pkg.Config, serviceName string) (func(), error) {
+	shutdown, err := tracing.Setup(ctx, &tracing.Config{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		Headers:     cfg.Tracing.Headers,
+		File:        cfg.Tracing.File,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		ServiceName: serviceName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		// Flush with a fresh context, the command's contexts are done by now
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdown(ctx); err != nil {
+			slog.Error("failed to flush traces", "error", err)
+		}
+	}, nil
+}
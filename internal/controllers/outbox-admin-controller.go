@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/internal/events"
+	"github.com/yaninyzwitty/grpc-products-service/internal/outbox"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultDeadLetterPageSize = 50
+	maxDeadLetterPageSize     = 500
+)
+
+type OutboxAdminController struct {
+	deadLetters store.DeadLetterStore
+	buckets     *outbox.BucketAssigner
+	replayer    *outbox.Replayer
+	pb.UnimplementedOutboxAdminServiceServer
+}
+
+func NewOutboxAdminController(deadLetters store.DeadLetterStore, buckets *outbox.BucketAssigner, replayer *outbox.Replayer) *OutboxAdminController {
+	return &OutboxAdminController{deadLetters: deadLetters, buckets: buckets, replayer: replayer}
+}
+
+func (c *OutboxAdminController) ListDeadLetters(ctx context.Context, req *pb.ListDeadLettersRequest) (*pb.ListDeadLettersResponse, error) {
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultDeadLetterPageSize
+	}
+	pageSize = min(pageSize, maxDeadLetterPageSize)
+
+	pageState, err := base64.URLEncoding.DecodeString(req.PageToken)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid page token")
+	}
+
+	deadLetters, nextPageState, err := c.deadLetters.ListDeadLetters(ctx, pageSize, pageState)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to list dead letters: %v", err)
+	}
+
+	res := &pb.ListDeadLettersResponse{
+		NextPageToken: base64.URLEncoding.EncodeToString(nextPageState),
+	}
+	for _, deadLetter := range deadLetters {
+		res.DeadLetters = append(res.DeadLetters, deadLetterToProto(deadLetter))
+	}
+	return res, nil
+}
+
+func (c *OutboxAdminController) GetDeadLetter(ctx context.Context, req *pb.GetDeadLetterRequest) (*pb.GetDeadLetterResponse, error) {
+	id, err := gocql.ParseUUID(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid dead letter id")
+	}
+
+	deadLetter, err := c.deadLetters.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, deadLetterError(err, "Failed to get dead letter")
+	}
+
+	return &pb.GetDeadLetterResponse{
+		DeadLetter: deadLetterToProto(*deadLetter),
+	}, nil
+}
+
+func (c *OutboxAdminController) RequeueDeadLetter(ctx context.Context, req *pb.RequeueDeadLetterRequest) (*pb.RequeueDeadLetterResponse, error) {
+	id, err := gocql.ParseUUID(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid dead letter id")
+	}
+
+	deadLetter, err := c.deadLetters.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, deadLetterError(err, "Failed to get dead letter")
+	}
+
+	// Requeue into the current window, the relay checkpoint may be past the
+	// original one. The shard follows the subject so its events stay together.
+	bucket, err := c.buckets.Assign(ctx, time.Now(), deadLetterSubject(*deadLetter))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to assign outbox bucket: %v", err)
+	}
+	if err := c.deadLetters.RequeueDeadLetter(ctx, id, bucket); err != nil {
+		return nil, deadLetterError(err, "Failed to requeue dead letter")
+	}
+
+	return &pb.RequeueDeadLetterResponse{
+		Bucket: bucket,
+	}, nil
+}
+
+func (c *OutboxAdminController) DiscardDeadLetter(ctx context.Context, req *pb.DiscardDeadLetterRequest) (*pb.DiscardDeadLetterResponse, error) {
+	id, err := gocql.ParseUUID(req.Id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "Invalid dead letter id")
+	}
+
+	if err := c.deadLetters.DiscardDeadLetter(ctx, id); err != nil {
+		return nil, deadLetterError(err, "Failed to discard dead letter")
+	}
+
+	return &pb.DiscardDeadLetterResponse{
+		Success: true,
+	}, nil
+}
+
+func (c *OutboxAdminController) ReplayEvents(ctx context.Context, req *pb.ReplayEventsRequest) (*pb.ReplayEventsResponse, error) {
+	hasRange := req.From != nil || req.To != nil
+	if !req.All && req.CategoryId == 0 && req.ProductId == 0 && !hasRange {
+		return nil, status.Errorf(codes.InvalidArgument, "A category, product, time range or all is required")
+	}
+	if req.ProductId != 0 && req.CategoryId == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "Replaying a product requires its category id")
+	}
+
+	filter := outbox.ReplayFilter{
+		CategoryID: req.CategoryId,
+		ProductID:  req.ProductId,
+	}
+	if req.From != nil {
+		filter.From = req.From.AsTime()
+	}
+	if req.To != nil {
+		filter.To = req.To.AsTime()
+	}
+	if hasRange && !filter.To.IsZero() && !filter.From.Before(filter.To) {
+		return nil, status.Errorf(codes.InvalidArgument, "The time range is empty")
+	}
+
+	job, err := c.replayer.Start(ctx, filter)
+	if errors.Is(err, outbox.ErrReplayRunning) {
+		return nil, status.Errorf(codes.FailedPrecondition, "A replay is already running")
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to start replay: %v", err)
+	}
+
+	return &pb.ReplayEventsResponse{
+		Job: replayJobToProto(job),
+	}, nil
+}
+
+func (c *OutboxAdminController) GetReplayJob(ctx context.Context, req *pb.GetReplayJobRequest) (*pb.GetReplayJobResponse, error) {
+	job, ok := c.replayer.Job(req.Id)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "Replay job not found")
+	}
+
+	return &pb.GetReplayJobResponse{
+		Job: replayJobToProto(job),
+	}, nil
+}
+
+func deadLetterError(err error, msg string) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "Dead letter not found")
+	}
+	return status.Errorf(codes.Internal, "%s: %v", msg, err)
+}
+
+// deadLetterSubject returns the subject of the dead letter's event, empty if
+// it cannot be decoded.
+func deadLetterSubject(deadLetter store.DeadLetter) string {
+	event := &pb.ProductEvent{}
+	if err := proto.Unmarshal(deadLetter.Data, event); err != nil {
+		return ""
+	}
+	return events.Subject(event)
+}
+
+// deadLetterToProto renders binary event data as protojson so it can be inspected.
+func deadLetterToProto(deadLetter store.DeadLetter) *pb.DeadLetter {
+	payload := deadLetter.Payload
+	if len(deadLetter.Data) > 0 {
+		event := &pb.ProductEvent{}
+		if err := proto.Unmarshal(deadLetter.Data, event); err == nil {
+			payload = protojson.Format(event)
+		}
+	}
+
+	return &pb.DeadLetter{
+		Id:             deadLetter.Id.String(),
+		Bucket:         deadLetter.Bucket,
+		EventType:      deadLetter.EventType,
+		Payload:        payload,
+		Attempts:       int32(deadLetter.Attempts),
+		LastError:      deadLetter.LastError,
+		DeadLetteredAt: timestamppb.New(deadLetter.DeadLetteredAt),
+	}
+}
+
+func replayJobToProto(job outbox.ReplayJob) *pb.ReplayJob {
+	replayJob := &pb.ReplayJob{
+		Id:        job.ID,
+		State:     job.State,
+		Enqueued:  int64(job.Enqueued),
+		StartedAt: timestamppb.New(job.StartedAt),
+	}
+	if job.Err != nil {
+		replayJob.Error = job.Err.Error()
+	}
+	if !job.FinishedAt.IsZero() {
+		replayJob.FinishedAt = timestamppb.New(job.FinishedAt)
+	}
+	return replayJob
+}
@@ -2,31 +2,40 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
 	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/internal/events"
+	"github.com/yaninyzwitty/grpc-products-service/internal/outbox"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/internal/tracing"
 	"github.com/yaninyzwitty/grpc-products-service/pb"
 	"github.com/yaninyzwitty/grpc-products-service/snowflake"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// firstEventSequence numbers the created event that starts the stream of a
+// product or category.
+const firstEventSequence uint64 = 1
+
 type ProductController struct {
-	session *gocql.Session
+	products   store.ProductStore
+	categories store.CategoryStore
+	buckets    *outbox.BucketAssigner
 	pb.UnimplementedProductsServiceServer
 }
 
-func NewProductController(session *gocql.Session) *ProductController {
-	return &ProductController{session: session}
+func NewProductController(products store.ProductStore, categories store.CategoryStore, buckets *outbox.BucketAssigner) *ProductController {
+	return &ProductController{products: products, categories: categories, buckets: buckets}
 }
 
 func (c *ProductController) CreateCategory(ctx context.Context, req *pb.CreateCategoryRequest) (*pb.CreateCategoryResponse, error) {
 	if req.Name == "" || req.Description == "" {
 		return nil, status.Errorf(codes.InvalidArgument, "Name and description are required")
 	}
-	createCategoryQuery := `INSERT INTO chat.categories(id, name, description, created_at) VALUES(?, ?, ?, ?)`
 
 	categoryId, err := snowflake.GenerateID()
 	if err != nil {
@@ -35,23 +44,35 @@ func (c *ProductController) CreateCategory(ctx context.Context, req *pb.CreateCa
 	}
 
 	now := time.Now()
-	if err := c.session.Query(
-		createCategoryQuery,
-		categoryId,
-		req.Name,
-		req.Description,
-		now,
-	).WithContext(ctx).Exec(); err != nil {
-		return nil, status.Errorf(codes.Internal, "Failed to create category")
+	category := &pb.Category{
+		Id:          int64(categoryId),
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedAt:   timestamppb.New(now),
 	}
 
-	return &pb.CreateCategoryResponse{
-		Category: &pb.Category{
-			Id:          int64(categoryId),
-			Name:        req.Name,
-			Description: req.Description,
-			CreatedAt:   timestamppb.New(now),
+	categoryEvent := &pb.ProductEvent{
+		Event: &pb.ProductEvent_CategoryCreated{
+			CategoryCreated: &pb.CategoryCreated{Category: category},
 		},
+	}
+
+	bucket, err := c.buckets.Assign(ctx, now, events.Subject(categoryEvent))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to assign outbox bucket: %v", err)
+	}
+
+	event, err := newOutboxEvent(ctx, categoryEvent, firstEventSequence, now, bucket)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to marshal category event: %v", err)
+	}
+
+	if err := c.categories.CreateCategory(ctx, category, event); err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to create category: %v", err)
+	}
+
+	return &pb.CreateCategoryResponse{
+		Category: category,
 	}, nil
 }
 func (c *ProductController) CreateProduct(ctx context.Context, req *pb.CreateProductRequest) (*pb.CreateProductResponse, error) {
@@ -65,10 +86,7 @@ func (c *ProductController) CreateProduct(ctx context.Context, req *pb.CreatePro
 		return nil, status.Errorf(codes.Internal, "Failed to generate product ID: %v", err)
 	}
 
-	outboxID := gocql.TimeUUID()
-	bucket := time.Now().Format("2006-01-02")
 	now := time.Now()
-	eventType := "CREATE_PRODUCT"
 
 	product := &pb.Product{
 		Id:          int64(productID),
@@ -81,32 +99,23 @@ func (c *ProductController) CreateProduct(ctx context.Context, req *pb.CreatePro
 		UpdatedAt:   timestamppb.New(now),
 	}
 
-	payload, err := json.Marshal(product)
+	productEvent := &pb.ProductEvent{
+		Event: &pb.ProductEvent_ProductCreated{
+			ProductCreated: &pb.ProductCreated{Product: product},
+		},
+	}
+
+	bucket, err := c.buckets.Assign(ctx, now, events.Subject(productEvent))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "Failed to assign outbox bucket: %v", err)
+	}
+
+	event, err := newOutboxEvent(ctx, productEvent, firstEventSequence, now, bucket)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to marshal product data: %v", err)
 	}
 
-	batch := c.session.NewBatch(gocql.LoggedBatch)
-	batch.WithContext(ctx)
-
-	batch.Query(
-		`INSERT INTO chat.products 
-		(id, name, description, price, stock, category_id, created_at, updated_at) 
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		product.Id, product.Name, product.Description, product.Price,
-		product.Stock, product.CategoryId, now, now,
-	)
-
-	// Add outbox event query
-	batch.Query(
-		`INSERT INTO chat.products_outbox 
-		(id, bucket, payload, event_type) 
-		VALUES (?, ?, ?, ?)`,
-		outboxID, bucket, payload, eventType,
-	)
-
-	// execute batch
-	if err := c.session.ExecuteBatch(batch); err != nil {
+	if err := c.products.CreateProduct(ctx, product, event); err != nil {
 		return nil, status.Errorf(codes.Internal, "Failed to create product: %v", err)
 	}
 
@@ -115,3 +124,27 @@ func (c *ProductController) CreateProduct(ctx context.Context, req *pb.CreatePro
 	}, nil
 
 }
+
+// newOutboxEvent stamps the event with a new outbox id and returns the outbox
+// row to write to the bucket in the same batch as the change it describes,
+// carrying the trace context of the request.
+func newOutboxEvent(ctx context.Context, event *pb.ProductEvent, sequence uint64, now time.Time, bucket string) (store.OutboxEvent, error) {
+	id := gocql.TimeUUID()
+	event.EventId = id.String()
+	event.OccurredAt = timestamppb.New(now)
+	event.Sequence = sequence
+
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return store.OutboxEvent{}, err
+	}
+
+	return store.OutboxEvent{
+		Id:           id,
+		Bucket:       bucket,
+		EventType:    events.EventType(event),
+		Data:         data,
+		Sequence:     sequence,
+		TraceContext: tracing.Inject(ctx),
+	}, nil
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/outbox"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"github.com/yaninyzwitty/grpc-products-service/snowflake"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// snowflakeErr is set when ids cannot be generated on this host, sonyflake
+// needs a private address.
+var snowflakeErr error
+
+func TestMain(m *testing.M) {
+	snowflakeErr = snowflake.InitSonyFlake()
+	os.Exit(m.Run())
+}
+
+const testCategoryID int64 = 10
+
+// outboxRows returns the outbox rows the controller wrote between start and
+// now, with a single shard per window.
+func outboxRows(t *testing.T, s *store.MemoryStore, start time.Time) []store.OutboxEvent {
+	t.Helper()
+
+	var rows []store.OutboxEvent
+	for _, window := range []string{store.WindowFor(start), store.WindowFor(time.Now())} {
+		bucket, err := s.ListOutboxEvents(context.Background(), store.BucketFor(window, 0))
+		if err != nil {
+			t.Fatalf("failed to list outbox events: %v", err)
+		}
+		rows = append(rows, bucket...)
+		if store.WindowFor(start) == store.WindowFor(time.Now()) {
+			break
+		}
+	}
+	return rows
+}
+
+func TestCreateProduct(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      *pb.CreateProductRequest
+		wantCode codes.Code
+	}{
+		{
+			name: "valid",
+			req:  &pb.CreateProductRequest{Name: "lamp", Description: "desk lamp", Price: 25, Stock: 3, CategoryId: testCategoryID},
+		},
+		{
+			name:     "missing name",
+			req:      &pb.CreateProductRequest{Description: "desk lamp", Price: 25, CategoryId: testCategoryID},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "missing category",
+			req:      &pb.CreateProductRequest{Name: "lamp", Description: "desk lamp", Price: 25},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "zero price",
+			req:      &pb.CreateProductRequest{Name: "lamp", Description: "desk lamp", CategoryId: testCategoryID},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantCode == codes.OK && snowflakeErr != nil {
+				t.Skipf("cannot generate ids: %v", snowflakeErr)
+			}
+
+			s := store.NewMemoryStore()
+			start := time.Now()
+			controller := NewProductController(s, s, outbox.NewBucketAssigner(1, s))
+			resp, err := controller.CreateProduct(context.Background(), tt.req)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("CreateProduct() error = %v, want code %v", err, tt.wantCode)
+			}
+
+			rows := outboxRows(t, s, start)
+			if tt.wantCode != codes.OK {
+				if len(rows) != 0 {
+					t.Errorf("%d outbox rows written for a rejected request", len(rows))
+				}
+				return
+			}
+
+			snapshot, err := s.GetProductSnapshot(context.Background(), tt.req.CategoryId, resp.Product.Id)
+			if err != nil {
+				t.Fatalf("product was not stored: %v", err)
+			}
+			if snapshot.Sequence != firstEventSequence {
+				t.Errorf("sequence = %d, want %d", snapshot.Sequence, firstEventSequence)
+			}
+
+			if len(rows) != 1 {
+				t.Fatalf("%d outbox rows written, want 1", len(rows))
+			}
+			event := &pb.ProductEvent{}
+			if err := proto.Unmarshal(rows[0].Data, event); err != nil {
+				t.Fatalf("failed to unmarshal outbox event: %v", err)
+			}
+			if event.GetProductCreated().GetProduct().GetId() != resp.Product.Id {
+				t.Errorf("outbox event = %v, want ProductCreated of product %d", event, resp.Product.Id)
+			}
+			if event.Sequence != firstEventSequence || rows[0].Sequence != firstEventSequence {
+				t.Errorf("event sequence %d, row sequence %d, want %d", event.Sequence, rows[0].Sequence, firstEventSequence)
+			}
+			if event.EventId != rows[0].Id.String() {
+				t.Errorf("event id = %q, want the outbox id %s", event.EventId, rows[0].Id)
+			}
+		})
+	}
+}
@@ -14,7 +14,10 @@ import (
 type AstraConfig struct {
 	Username string
 	Path     string
-	Token    string
+	// Token returns the current token, it is called whenever a connection
+	// authenticates so rotated tokens apply to new connections.
+	Token  func() (string, error)
+	Driver *DriverConfig // nil keeps the gocql defaults
 }
 
 // AstraMethods defines the methods for interacting with Astra DB.
@@ -30,14 +33,21 @@ func NewAstraDB() AstraMethods {
 	return &AstraDB{}
 }
 
-// Connect establishes a connection to Astra DB and returns a session.
+// Connect establishes a connection to Astra DB and returns a session, timeout
+// bounds connecting.
 func (db *AstraDB) Connect(ctx context.Context, cfg *AstraConfig, timeout time.Duration) (*gocql.Session, error) {
 	// Create a new Astra DB cluster configuration using the provided credentials and timeout.
-	cluster, err := gocqlastra.NewClusterFromBundle(cfg.Path, cfg.Username, cfg.Token, timeout)
+	cluster, err := gocqlastra.NewClusterFromBundle(cfg.Path, cfg.Username, "", timeout)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Astra DB cluster from bundle: %w", err)
 	}
 
+	applyDriver(cluster, cfg.Driver)
+
+	if base, ok := cluster.Authenticator.(*gocql.PasswordAuthenticator); ok {
+		cluster.Authenticator = &passwordAuthenticator{base: *base, password: cfg.Token}
+	}
+
 	// Open a new session using the cluster configuration.
 	session, err := gocql.NewSession(*cluster)
 	if err != nil {
@@ -0,0 +1,29 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/gocql/gocql"
+)
+
+// passwordAuthenticator authenticates every connection with the password,
+// or Astra token, current at the time it connects.
+type passwordAuthenticator struct {
+	base     gocql.PasswordAuthenticator
+	password func() (string, error)
+}
+
+func (a *passwordAuthenticator) Challenge(req []byte) ([]byte, gocql.Authenticator, error) {
+	password, err := a.password()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get database credentials: %w", err)
+	}
+
+	auth := a.base
+	auth.Password = password
+	return auth.Challenge(req)
+}
+
+func (a *passwordAuthenticator) Success(data []byte) error {
+	return nil
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// CassandraConfig holds the configuration for connecting to a self-hosted
+// Cassandra or ScyllaDB cluster.
+type CassandraConfig struct {
+	Hosts    []string // contact points, host or host:port
+	Port     int      // used for contact points without a port, defaults to 9042
+	Username string   // empty disables authentication
+	// Password returns the current password, it is called whenever a
+	// connection authenticates.
+	Password func() (string, error)
+	// LocalDC routes queries to replicas of this datacenter first.
+	LocalDC string
+	TLS     *TLSConfig    // nil connects in plain text
+	Driver  *DriverConfig // nil keeps the gocql defaults
+}
+
+// TLSConfig holds the certificates for connecting to the cluster over TLS.
+type TLSConfig struct {
+	CAFile             string // verifies the nodes' certificates
+	CertFile           string // client certificate, for clusters requiring client auth
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// CassandraMethods defines the methods for interacting with a self-hosted cluster.
+type CassandraMethods interface {
+	Connect(ctx context.Context, cfg *CassandraConfig, timeout time.Duration) (*gocql.Session, error)
+}
+
+// CassandraDB represents a connection to a self-hosted Cassandra or ScyllaDB cluster.
+type CassandraDB struct{}
+
+// NewCassandraDB initializes and returns a CassandraDB instance that implements CassandraMethods.
+func NewCassandraDB() CassandraMethods {
+	return &CassandraDB{}
+}
+
+// Connect establishes a connection to the cluster and returns a session,
+// timeout bounds connecting and, unless the driver config sets one, queries.
+func (db *CassandraDB) Connect(ctx context.Context, cfg *CassandraConfig, timeout time.Duration) (*gocql.Session, error) {
+	if len(cfg.Hosts) == 0 {
+		return nil, errors.New("no Cassandra contact points configured")
+	}
+
+	cluster := gocql.NewCluster(cfg.Hosts...)
+	cluster.Timeout = timeout
+	cluster.ConnectTimeout = timeout
+	if cfg.Port > 0 {
+		cluster.Port = cfg.Port
+	}
+
+	applyDriver(cluster, cfg.Driver)
+
+	if cfg.LocalDC != "" {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(cfg.LocalDC))
+	}
+
+	if cfg.Username != "" {
+		if cfg.Password == nil {
+			return nil, errors.New("a Cassandra username requires a password")
+		}
+		cluster.Authenticator = &passwordAuthenticator{
+			base:     gocql.PasswordAuthenticator{Username: cfg.Username},
+			password: cfg.Password,
+		}
+	}
+
+	if cfg.TLS != nil {
+		cluster.SslOpts = &gocql.SslOptions{
+			CaPath:                 cfg.TLS.CAFile,
+			CertPath:               cfg.TLS.CertFile,
+			KeyPath:                cfg.TLS.KeyFile,
+			EnableHostVerification: !cfg.TLS.InsecureSkipVerify,
+		}
+	}
+
+	session, err := cluster.CreateSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	slog.Info("Successfully connected to Cassandra", "hosts", cfg.Hosts, "localDC", cfg.LocalDC)
+
+	return session, nil
+}
@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// QueryType classifies the queries of the store so each type can be tuned
+// separately.
+type QueryType string
+
+const (
+	QueryRead  QueryType = "read"  // single partition lookups
+	QueryScan  QueryType = "scan"  // paged reads over many rows
+	QueryWrite QueryType = "write" // plain writes and logged batches
+	QueryLWT   QueryType = "lwt"   // conditional writes
+)
+
+// QueryTypes lists every query type.
+var QueryTypes = []QueryType{QueryRead, QueryScan, QueryWrite, QueryLWT}
+
+// DriverConfig tunes the gocql cluster of both Astra and self-hosted
+// connections.
+type DriverConfig struct {
+	QueryTimeout time.Duration
+	NumConns     int // connections per host
+
+	// Cluster defaults, used by queries whose profile does not override them
+	Consistency       gocql.Consistency
+	SerialConsistency gocql.SerialConsistency
+	PageSize          int
+	RetryPolicy       gocql.RetryPolicy
+
+	Profiles QueryProfiles
+
+	// Observers see every query and batch attempt, e.g. to record metrics
+	// and spans
+	QueryObserver gocql.QueryObserver
+	BatchObserver gocql.BatchObserver
+}
+
+// Observer sees every query and batch attempt.
+type Observer interface {
+	gocql.QueryObserver
+	gocql.BatchObserver
+}
+
+// Observers passes every query and batch attempt to each of its observers,
+// e.g. to record both metrics and spans.
+type Observers []Observer
+
+func (o Observers) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	for _, observer := range o {
+		observer.ObserveQuery(ctx, q)
+	}
+}
+
+func (o Observers) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	for _, observer := range o {
+		observer.ObserveBatch(ctx, b)
+	}
+}
+
+// QueryProfile is the driver behaviour of one query type. Zero fields keep
+// the cluster defaults.
+type QueryProfile struct {
+	Consistency       gocql.Consistency // ANY cannot be selected, it means unset
+	SerialConsistency gocql.SerialConsistency
+	PageSize          int
+	RetryPolicy       gocql.RetryPolicy
+	// Speculative sends the query to another host when the first is slow,
+	// only idempotent query types use it.
+	Speculative gocql.SpeculativeExecutionPolicy
+	Idempotent  bool
+}
+
+// QueryProfiles holds the profile of every query type, a nil QueryProfiles
+// keeps the cluster defaults for all of them.
+type QueryProfiles map[QueryType]QueryProfile
+
+// Query applies the profile of the query type to q.
+func (p QueryProfiles) Query(q *gocql.Query, queryType QueryType) *gocql.Query {
+	profile, ok := p[queryType]
+	if !ok {
+		return q
+	}
+
+	if profile.Consistency != gocql.Any {
+		q.Consistency(profile.Consistency)
+	}
+	if profile.SerialConsistency != 0 {
+		q.SerialConsistency(profile.SerialConsistency)
+	}
+	if profile.PageSize > 0 {
+		q.PageSize(profile.PageSize)
+	}
+	if profile.RetryPolicy != nil {
+		q.RetryPolicy(profile.RetryPolicy)
+	}
+	if profile.Idempotent {
+		q.Idempotent(true)
+		if profile.Speculative != nil {
+			q.SetSpeculativeExecutionPolicy(profile.Speculative)
+		}
+	}
+	return q
+}
+
+// Batch applies the write profile to b.
+func (p QueryProfiles) Batch(b *gocql.Batch) *gocql.Batch {
+	profile, ok := p[QueryWrite]
+	if !ok {
+		return b
+	}
+
+	if profile.Consistency != gocql.Any {
+		b.SetConsistency(profile.Consistency)
+	}
+	if profile.SerialConsistency != 0 {
+		b.SerialConsistency(profile.SerialConsistency)
+	}
+	if profile.RetryPolicy != nil {
+		b.RetryPolicy(profile.RetryPolicy)
+	}
+	return b
+}
+
+// applyDriver sets the driver defaults on the cluster, a nil config keeps
+// the gocql defaults.
+func applyDriver(cluster *gocql.ClusterConfig, cfg *DriverConfig) {
+	if cfg == nil {
+		return
+	}
+
+	if cfg.QueryTimeout > 0 {
+		cluster.Timeout = cfg.QueryTimeout
+	}
+	if cfg.NumConns > 0 {
+		cluster.NumConns = cfg.NumConns
+	}
+	if cfg.Consistency != gocql.Any {
+		cluster.Consistency = cfg.Consistency
+	}
+	if cfg.SerialConsistency != 0 {
+		cluster.SerialConsistency = cfg.SerialConsistency
+	}
+	if cfg.PageSize > 0 {
+		cluster.PageSize = cfg.PageSize
+	}
+	if cfg.RetryPolicy != nil {
+		cluster.RetryPolicy = cfg.RetryPolicy
+	}
+	cluster.QueryObserver = cfg.QueryObserver
+	cluster.BatchObserver = cfg.BatchObserver
+}
+
+// RetryPolicy returns a policy retrying up to retries times with
+// exponential backoff between minBackoff and maxBackoff.
+func RetryPolicy(retries int, minBackoff, maxBackoff time.Duration) gocql.RetryPolicy {
+	if retries <= 0 {
+		return &gocql.SimpleRetryPolicy{NumRetries: 0}
+	}
+	return &gocql.ExponentialBackoffRetryPolicy{
+		NumRetries: retries,
+		Min:        minBackoff,
+		Max:        maxBackoff,
+	}
+}
+
+// SpeculativePolicy returns a policy sending up to attempts extra
+// executions, delay apart, or nil when attempts is zero.
+func SpeculativePolicy(attempts int, delay time.Duration) gocql.SpeculativeExecutionPolicy {
+	if attempts <= 0 {
+		return nil
+	}
+	return &gocql.SimpleSpeculativeExecution{NumAttempts: attempts, TimeoutDelay: delay}
+}
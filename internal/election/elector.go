@@ -0,0 +1,288 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+// DefaultTTL is used when no lease TTL is configured.
+const DefaultTTL = 15 * time.Second
+
+// ErrLeadershipLost is the cause of a Lead context cancelled because the
+// lease was lost or ran out.
+var ErrLeadershipLost = errors.New("leadership lost")
+
+var (
+	leaseHolder = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "products_relay_lease_holder",
+		Help: "Set to 1 for the replica this instance last observed holding the lease.",
+	}, []string{"lease", "holder"})
+
+	isLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "products_relay_is_leader",
+		Help: "Whether this instance currently holds the lease.",
+	}, []string{"lease"})
+)
+
+// Config holds the configuration for a leader elector.
+type Config struct {
+	Name   string
+	Holder string
+	TTL    time.Duration
+}
+
+// Elector campaigns for a named lease and keeps it alive with heartbeats.
+type Elector struct {
+	leases store.LeaseStore
+	name   string
+	holder string
+	ttl    time.Duration
+
+	mu       sync.RWMutex
+	leader   bool
+	renewed  time.Time
+	observed string
+	// term is cancelled when leadership ends, either because the lease was
+	// lost or because it ran out while renewals kept failing.
+	term       context.Context
+	cancelTerm context.CancelCauseFunc
+	expiry     *time.Timer
+}
+
+// NewElector initializes and returns an Elector for the given lease.
+func NewElector(cfg *Config, leases store.LeaseStore) *Elector {
+	holder := cfg.Holder
+	if holder == "" {
+		holder = DefaultHolder()
+	}
+
+	ttl := cfg.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Elector{
+		leases: leases,
+		name:   cfg.Name,
+		holder: holder,
+		ttl:    ttl,
+	}
+}
+
+// DefaultHolder identifies this process by hostname plus a random suffix, so
+// a restarted task never mistakes its predecessor's lease for its own.
+func DefaultHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%s", hostname, gocql.TimeUUID().String()[:8])
+}
+
+// Holder returns the identity this elector campaigns with.
+func (e *Elector) Holder() string {
+	return e.holder
+}
+
+// IsLeader reports whether this instance holds the lease. Leadership is
+// dropped locally once the lease would have expired without a renewal, even
+// if the store could not be reached to confirm it.
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	return e.leader && time.Since(e.renewed) < e.ttl
+}
+
+// Lead returns a context derived from ctx that is cancelled with
+// ErrLeadershipLost as soon as this instance stops being the leader, so work
+// started as the leader stops with it. It returns false when this instance
+// is not the leader. The returned function releases the context.
+func (e *Elector) Lead(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	e.mu.RLock()
+	term := e.term
+	leading := e.leader && time.Since(e.renewed) < e.ttl
+	e.mu.RUnlock()
+
+	if !leading || term == nil {
+		return ctx, func() {}, false
+	}
+
+	leadCtx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(term, func() {
+		cancel(context.Cause(term))
+	})
+	return leadCtx, func() {
+		stop()
+		cancel(nil)
+	}, true
+}
+
+// Run campaigns for the lease until ctx is done, then releases it so another
+// replica can take over without waiting for the TTL to expire.
+func (e *Elector) Run(ctx context.Context) {
+	// Heartbeat well within the TTL so a single missed round does not cost the lease.
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	e.tick(ctx)
+	for {
+		select {
+		case <-ticker.C:
+			e.tick(ctx)
+		case <-ctx.Done():
+			e.release()
+			return
+		}
+	}
+}
+
+func (e *Elector) tick(ctx context.Context) {
+	if e.IsLeader() {
+		e.renew(ctx)
+		return
+	}
+	e.campaign(ctx)
+}
+
+func (e *Elector) campaign(ctx context.Context) {
+	start := time.Now()
+
+	holder, acquired, err := e.leases.TryAcquireLease(ctx, e.name, e.holder, e.ttl)
+	if err != nil {
+		slog.Error("failed to acquire lease", "lease", e.name, "error", err)
+		e.setLeader(false, time.Time{})
+		return
+	}
+
+	e.observe(holder)
+	switch {
+	case acquired:
+		slog.Info("acquired lease", "lease", e.name, "holder", e.holder)
+		e.setLeader(true, start)
+	case holder == e.holder:
+		// Our own earlier lease is still live but may run out before a full
+		// TTL from now, lead only once it was extended
+		e.renew(ctx)
+	default:
+		e.setLeader(false, time.Time{})
+	}
+}
+
+func (e *Elector) renew(ctx context.Context) {
+	start := time.Now()
+
+	ok, err := e.leases.RenewLease(ctx, e.name, e.holder, e.ttl)
+	if err != nil {
+		// Keep leading until the previous renewal runs out; IsLeader enforces the deadline.
+		slog.Error("failed to renew lease", "lease", e.name, "error", err)
+		return
+	}
+	if !ok {
+		slog.Warn("lost lease", "lease", e.name, "holder", e.holder)
+		e.setLeader(false, time.Time{})
+		e.observe("")
+		return
+	}
+
+	e.setLeader(true, start)
+}
+
+func (e *Elector) release() {
+	if !e.IsLeader() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl/3)
+	defer cancel()
+
+	if err := e.leases.ReleaseLease(ctx, e.name, e.holder); err != nil {
+		slog.Error("failed to release lease", "lease", e.name, "error", err)
+	} else {
+		slog.Info("released lease", "lease", e.name, "holder", e.holder)
+	}
+
+	e.setLeader(false, time.Time{})
+	e.observe("")
+}
+
+func (e *Elector) setLeader(leader bool, renewed time.Time) {
+	e.mu.Lock()
+	e.leader = leader
+	e.renewed = renewed
+	if leader {
+		e.extendTerm(renewed)
+	} else {
+		e.endTerm()
+	}
+	e.mu.Unlock()
+
+	if leader {
+		isLeader.WithLabelValues(e.name).Set(1)
+	} else {
+		isLeader.WithLabelValues(e.name).Set(0)
+	}
+}
+
+// extendTerm starts a term when leadership is gained, or regained after the
+// previous term ran out, and moves its end to when the lease renewed at
+// renewed runs out. Called with mu held.
+func (e *Elector) extendTerm(renewed time.Time) {
+	if e.term == nil || e.term.Err() != nil {
+		e.term, e.cancelTerm = context.WithCancelCause(context.Background())
+	}
+	if e.expiry != nil {
+		e.expiry.Stop()
+	}
+
+	cancel := e.cancelTerm
+	e.expiry = time.AfterFunc(e.ttl-time.Since(renewed), func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+
+		// A renewal may have moved the deadline while the timer fired
+		if time.Since(e.renewed) >= e.ttl {
+			cancel(ErrLeadershipLost)
+		}
+	})
+}
+
+// endTerm cancels the current term. Called with mu held.
+func (e *Elector) endTerm() {
+	if e.expiry != nil {
+		e.expiry.Stop()
+		e.expiry = nil
+	}
+	if e.cancelTerm != nil {
+		e.cancelTerm(ErrLeadershipLost)
+	}
+	e.term, e.cancelTerm = nil, nil
+}
+
+// observe records the holder this instance last saw, so the metric always
+// carries a single series per lease.
+func (e *Elector) observe(holder string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if holder == e.observed {
+		return
+	}
+	if e.observed != "" {
+		leaseHolder.DeleteLabelValues(e.name, e.observed)
+	}
+	if holder != "" {
+		leaseHolder.WithLabelValues(e.name, holder).Set(1)
+	}
+	e.observed = holder
+}
@@ -0,0 +1,172 @@
+package election
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+const testLease = "outbox-relay"
+
+func TestElectorCampaign(t *testing.T) {
+	tests := []struct {
+		name string
+		// heldBy holds the lease before the elector campaigns, if set
+		heldBy     string
+		wantLeader bool
+	}{
+		{name: "free lease", wantLeader: true},
+		{name: "lease held by another replica", heldBy: "other"},
+		{name: "own earlier lease still live", heldBy: "self", wantLeader: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			leases := store.NewMemoryStore()
+			if tt.heldBy != "" {
+				if _, _, err := leases.TryAcquireLease(ctx, testLease, tt.heldBy, time.Minute); err != nil {
+					t.Fatalf("failed to acquire lease: %v", err)
+				}
+			}
+
+			e := NewElector(&Config{Name: testLease, Holder: "self", TTL: time.Minute}, leases)
+			e.tick(ctx)
+			defer e.release()
+
+			if e.IsLeader() != tt.wantLeader {
+				t.Errorf("IsLeader() = %v, want %v", e.IsLeader(), tt.wantLeader)
+			}
+
+			leadCtx, cancel, ok := e.Lead(ctx)
+			defer cancel()
+			if ok != tt.wantLeader {
+				t.Fatalf("Lead() ok = %v, want %v", ok, tt.wantLeader)
+			}
+			if ok && leadCtx.Err() != nil {
+				t.Errorf("Lead() context is done: %v", context.Cause(leadCtx))
+			}
+		})
+	}
+}
+
+func TestElectorTermEnds(t *testing.T) {
+	const ttl = 150 * time.Millisecond
+
+	tests := []struct {
+		name string
+		// end ends the leadership of the elector
+		end func(t *testing.T, e *Elector, leases *store.MemoryStore)
+	}{
+		{
+			name: "lease taken over",
+			end: func(t *testing.T, e *Elector, leases *store.MemoryStore) {
+				ctx := context.Background()
+				if err := leases.ReleaseLease(ctx, testLease, e.Holder()); err != nil {
+					t.Fatalf("failed to release lease: %v", err)
+				}
+				if _, _, err := leases.TryAcquireLease(ctx, testLease, "other", time.Minute); err != nil {
+					t.Fatalf("failed to acquire lease: %v", err)
+				}
+				e.tick(ctx)
+			},
+		},
+		{
+			name: "lease runs out without renewals",
+			end:  func(*testing.T, *Elector, *store.MemoryStore) {},
+		},
+		{
+			name: "lease released",
+			end: func(_ *testing.T, e *Elector, _ *store.MemoryStore) {
+				e.release()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			leases := store.NewMemoryStore()
+			e := NewElector(&Config{Name: testLease, Holder: "self", TTL: ttl}, leases)
+			e.tick(context.Background())
+
+			leadCtx, cancel, ok := e.Lead(context.Background())
+			defer cancel()
+			if !ok {
+				t.Fatal("Lead() ok = false after acquiring the lease")
+			}
+
+			tt.end(t, e, leases)
+
+			select {
+			case <-leadCtx.Done():
+			case <-time.After(10 * ttl):
+				t.Fatal("Lead() context was not cancelled")
+			}
+			if cause := context.Cause(leadCtx); !errors.Is(cause, ErrLeadershipLost) {
+				t.Errorf("cause = %v, want ErrLeadershipLost", cause)
+			}
+			if e.IsLeader() {
+				t.Error("IsLeader() = true after leadership ended")
+			}
+			if _, _, ok := e.Lead(context.Background()); ok {
+				t.Error("Lead() ok = true after leadership ended")
+			}
+		})
+	}
+}
+
+func TestElectorRenewalKeepsTerm(t *testing.T) {
+	const ttl = 150 * time.Millisecond
+
+	leases := store.NewMemoryStore()
+	e := NewElector(&Config{Name: testLease, Holder: "self", TTL: ttl}, leases)
+	e.tick(context.Background())
+	defer e.release()
+
+	leadCtx, cancel, ok := e.Lead(context.Background())
+	defer cancel()
+	if !ok {
+		t.Fatal("Lead() ok = false after acquiring the lease")
+	}
+
+	// Renewing within the TTL moves the end of the term every time
+	for range 4 {
+		time.Sleep(ttl / 3)
+		e.tick(context.Background())
+	}
+
+	if leadCtx.Err() != nil {
+		t.Errorf("Lead() context is done after renewals: %v", context.Cause(leadCtx))
+	}
+}
+
+// expiringLeases reports every lease as lost on renewal, as when the lease
+// of an earlier run of this holder runs out right after the campaign saw it.
+type expiringLeases struct {
+	*store.MemoryStore
+}
+
+func (expiringLeases) RenewLease(context.Context, string, string, time.Duration) (bool, error) {
+	return false, nil
+}
+
+func TestElectorCampaignOwnLeaseNotRenewed(t *testing.T) {
+	ctx := context.Background()
+	leases := expiringLeases{store.NewMemoryStore()}
+	if _, _, err := leases.TryAcquireLease(ctx, testLease, "self", time.Minute); err != nil {
+		t.Fatalf("failed to acquire lease: %v", err)
+	}
+
+	e := NewElector(&Config{Name: testLease, Holder: "self", TTL: time.Minute}, leases)
+	e.tick(ctx)
+
+	if e.IsLeader() {
+		t.Error("IsLeader() = true for an own lease that could not be renewed")
+	}
+	if _, _, ok := e.Lead(ctx); ok {
+		t.Error("Lead() ok = true for an own lease that could not be renewed")
+	}
+}
@@ -0,0 +1,245 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	SpecVersion = "1.0"
+
+	// DefaultSource is the CloudEvents source used when none is configured.
+	DefaultSource = "//github.com/yaninyzwitty/grpc-products-service"
+
+	// TypePrefix namespaces every event type emitted by this service.
+	TypePrefix = "com.github.yaninyzwitty.products."
+
+	ContentTypeJSON       = "application/json"
+	ContentTypeProtobuf   = "application/protobuf"
+	ContentTypeCloudEvent = "application/cloudevents+json"
+
+	// PropertyContentType carries the content type as a Pulsar message property.
+	PropertyContentType = "content-type"
+
+	// binaryPropertyPrefix prefixes CloudEvents attributes in binary mode.
+	binaryPropertyPrefix = "ce_"
+)
+
+// Mode selects how CloudEvents are mapped onto Pulsar messages.
+type Mode string
+
+const (
+	// ModeStructured puts the whole envelope in the message payload.
+	ModeStructured Mode = "structured"
+	// ModeBinary puts the data in the payload and the attributes in message properties.
+	ModeBinary Mode = "binary"
+)
+
+// Delivery guarantees carried in the deliveryguarantee extension attribute.
+const (
+	// DeliveryAtLeastOnce events may be published more than once.
+	DeliveryAtLeastOnce = "at-least-once"
+	// DeliveryEffectivelyOnce events are deduplicated by the broker; a
+	// duplicate only follows a publish that failed after reaching it.
+	DeliveryEffectivelyOnce = "effectively-once"
+)
+
+// Format selects how event data is serialized.
+type Format string
+
+const (
+	// FormatProtobuf serializes data as binary protobuf, published with a Pulsar protobuf schema.
+	FormatProtobuf Format = "protobuf"
+	// FormatJSON serializes data with protojson on a schemaless topic.
+	FormatJSON Format = "json"
+)
+
+// Config holds the configuration for the CloudEvents encoder.
+type Config struct {
+	Source string
+	Mode   Mode
+	Format Format
+}
+
+// Event is a CloudEvents 1.0 event before it is encoded. Sequence, Delivery
+// and Replay are carried in extension attributes when set.
+type Event struct {
+	Id       string
+	Type     string
+	Subject  string
+	Time     time.Time
+	Sequence uint64
+	Delivery string
+	Replay   bool // regenerated from the current state rather than a new change
+	Data     proto.Message
+}
+
+// Message is an encoded event ready to be handed to a producer.
+type Message struct {
+	Payload    []byte
+	Properties map[string]string
+}
+
+// envelope is the structured-mode JSON representation of an event.
+type envelope struct {
+	SpecVersion     string          `json:"specversion"`
+	Id              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time"`
+	Sequence        string          `json:"sequence,omitempty"`
+	Delivery        string          `json:"deliveryguarantee,omitempty"`
+	Replay          bool            `json:"replay,omitempty"`
+	DataContentType string          `json:"datacontenttype"`
+	Data            json.RawMessage `json:"data"`
+}
+
+// Encoder wraps events in CloudEvents envelopes.
+type Encoder struct {
+	source string
+	mode   Mode
+	format Format
+}
+
+// NewEncoder initializes and returns an Encoder for the configured mode and
+// format. Protobuf data can only be carried in binary mode.
+func NewEncoder(cfg *Config) (*Encoder, error) {
+	e := &Encoder{
+		source: cfg.Source,
+		mode:   cfg.Mode,
+		format: cfg.Format,
+	}
+
+	if e.source == "" {
+		e.source = DefaultSource
+	}
+	switch e.format {
+	case "":
+		e.format = FormatProtobuf
+	case FormatProtobuf, FormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown event format %q", cfg.Format)
+	}
+	switch e.mode {
+	case "":
+		e.mode = ModeStructured
+		if e.format == FormatProtobuf {
+			e.mode = ModeBinary
+		}
+	case ModeStructured, ModeBinary:
+	default:
+		return nil, fmt.Errorf("unknown cloudevents mode %q", cfg.Mode)
+	}
+	if e.format == FormatProtobuf && e.mode != ModeBinary {
+		return nil, fmt.Errorf("cloudevents mode %q requires the %q event format", e.mode, FormatJSON)
+	}
+
+	return e, nil
+}
+
+// Format returns the format event data is serialized with.
+func (e *Encoder) Format() Format {
+	return e.format
+}
+
+// Encode renders the event according to the encoder's mode. JSON data is
+// serialized with protojson so timestamps use their canonical RFC 3339 form.
+func (e *Encoder) Encode(event Event) (*Message, error) {
+	var (
+		data        []byte
+		contentType string
+		err         error
+	)
+	if e.format == FormatProtobuf {
+		data, err = proto.Marshal(event.Data)
+		contentType = ContentTypeProtobuf
+	} else {
+		data, err = protojson.Marshal(event.Data)
+		contentType = ContentTypeJSON
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event data: %w", err)
+	}
+
+	eventTime := event.Time.UTC().Format(time.RFC3339Nano)
+
+	var sequence string
+	if event.Sequence > 0 {
+		sequence = strconv.FormatUint(event.Sequence, 10)
+	}
+
+	if e.mode == ModeBinary {
+		properties := map[string]string{
+			PropertyContentType:                  contentType,
+			binaryPropertyPrefix + "specversion": SpecVersion,
+			binaryPropertyPrefix + "id":          event.Id,
+			binaryPropertyPrefix + "source":      e.source,
+			binaryPropertyPrefix + "type":        event.Type,
+			binaryPropertyPrefix + "subject":     event.Subject,
+			binaryPropertyPrefix + "time":        eventTime,
+		}
+		if sequence != "" {
+			properties[binaryPropertyPrefix+"sequence"] = sequence
+		}
+		if event.Delivery != "" {
+			properties[binaryPropertyPrefix+"deliveryguarantee"] = event.Delivery
+		}
+		if event.Replay {
+			properties[binaryPropertyPrefix+"replay"] = "true"
+		}
+		return &Message{
+			Payload:    data,
+			Properties: properties,
+		}, nil
+	}
+
+	payload, err := json.Marshal(envelope{
+		SpecVersion:     SpecVersion,
+		Id:              event.Id,
+		Source:          e.source,
+		Type:            event.Type,
+		Subject:         event.Subject,
+		Time:            eventTime,
+		Sequence:        sequence,
+		Delivery:        event.Delivery,
+		Replay:          event.Replay,
+		DataContentType: ContentTypeJSON,
+		Data:            data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event envelope: %w", err)
+	}
+
+	return &Message{
+		Payload: payload,
+		Properties: map[string]string{
+			PropertyContentType: ContentTypeCloudEvent,
+		},
+	}, nil
+}
+
+// TypeFor maps an outbox event type such as CREATE_PRODUCT to its CloudEvents
+// type, e.g. com.github.yaninyzwitty.products.product.created.
+func TypeFor(eventType string) string {
+	verb, noun, ok := strings.Cut(strings.ToLower(eventType), "_")
+	if !ok {
+		return TypePrefix + strings.ToLower(eventType)
+	}
+
+	switch verb {
+	case "create":
+		verb = "created"
+	case "update":
+		verb = "updated"
+	case "delete":
+		verb = "deleted"
+	}
+	return TypePrefix + noun + "." + verb
+}
@@ -0,0 +1,181 @@
+package events
+
+import (
+	"encoding/json"
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var testEvent = Event{
+	Id:       "8d3c7a4e-adce-11f0-8de9-0242ac120002",
+	Type:     TypeFor(CreateProduct),
+	Subject:  "7",
+	Time:     time.Date(2026, time.October, 19, 12, 30, 0, 0, time.FixedZone("EAT", 3*60*60)),
+	Sequence: 2,
+	Delivery: DeliveryAtLeastOnce,
+	Data:     &pb.Product{Id: 7, Name: "lamp", CategoryId: 1},
+}
+
+func TestNewEncoder(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    *Encoder
+		wantErr bool
+	}{
+		{
+			name: "defaults to binary protobuf",
+			want: &Encoder{source: DefaultSource, mode: ModeBinary, format: FormatProtobuf},
+		},
+		{
+			name: "json defaults to structured",
+			cfg:  Config{Format: FormatJSON},
+			want: &Encoder{source: DefaultSource, mode: ModeStructured, format: FormatJSON},
+		},
+		{
+			name: "binary json",
+			cfg:  Config{Source: "//products", Mode: ModeBinary, Format: FormatJSON},
+			want: &Encoder{source: "//products", mode: ModeBinary, format: FormatJSON},
+		},
+		{name: "structured protobuf", cfg: Config{Mode: ModeStructured, Format: FormatProtobuf}, wantErr: true},
+		{name: "unknown mode", cfg: Config{Mode: "batched"}, wantErr: true},
+		{name: "unknown format", cfg: Config{Format: "avro"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewEncoder(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewEncoder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && *got != *tt.want {
+				t.Errorf("NewEncoder() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestEncoderEncodeBinary(t *testing.T) {
+	tests := []struct {
+		name            string
+		format          Format
+		wantContentType string
+		unmarshal       func([]byte, proto.Message) error
+	}{
+		{name: "protobuf", format: FormatProtobuf, wantContentType: ContentTypeProtobuf, unmarshal: proto.Unmarshal},
+		{name: "json", format: FormatJSON, wantContentType: ContentTypeJSON, unmarshal: protojson.Unmarshal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder, err := NewEncoder(&Config{Mode: ModeBinary, Format: tt.format})
+			if err != nil {
+				t.Fatalf("failed to create encoder: %v", err)
+			}
+
+			msg, err := encoder.Encode(testEvent)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+
+			want := map[string]string{
+				PropertyContentType:    tt.wantContentType,
+				"ce_specversion":       SpecVersion,
+				"ce_id":                testEvent.Id,
+				"ce_source":            DefaultSource,
+				"ce_type":              "com.github.yaninyzwitty.products.product.created",
+				"ce_subject":           "7",
+				"ce_time":              "2026-10-19T09:30:00Z",
+				"ce_sequence":          "2",
+				"ce_deliveryguarantee": DeliveryAtLeastOnce,
+			}
+			if !maps.Equal(msg.Properties, want) {
+				t.Errorf("properties = %v, want %v", msg.Properties, want)
+			}
+
+			product := &pb.Product{}
+			if err := tt.unmarshal(msg.Payload, product); err != nil {
+				t.Fatalf("failed to unmarshal payload: %v", err)
+			}
+			if !proto.Equal(product, testEvent.Data) {
+				t.Errorf("payload = %v, want %v", product, testEvent.Data)
+			}
+		})
+	}
+}
+
+func TestEncoderEncodeStructured(t *testing.T) {
+	encoder, err := NewEncoder(&Config{Source: "//products", Format: FormatJSON})
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+
+	event := testEvent
+	event.Sequence = 0
+	event.Replay = true
+	msg, err := encoder.Encode(event)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if got := msg.Properties[PropertyContentType]; got != ContentTypeCloudEvent {
+		t.Errorf("content type = %q, want %q", got, ContentTypeCloudEvent)
+	}
+
+	var got map[string]json.RawMessage
+	if err := json.Unmarshal(msg.Payload, &got); err != nil {
+		t.Fatalf("failed to unmarshal envelope: %v", err)
+	}
+	want := map[string]string{
+		"specversion":       `"1.0"`,
+		"id":                `"` + testEvent.Id + `"`,
+		"source":            `"//products"`,
+		"type":              `"com.github.yaninyzwitty.products.product.created"`,
+		"subject":           `"7"`,
+		"time":              `"2026-10-19T09:30:00Z"`,
+		"deliveryguarantee": `"at-least-once"`,
+		"replay":            `true`,
+		"datacontenttype":   `"application/json"`,
+	}
+	for attribute, value := range want {
+		if string(got[attribute]) != value {
+			t.Errorf("%s = %s, want %s", attribute, got[attribute], value)
+		}
+	}
+	if _, ok := got["sequence"]; ok {
+		t.Error("sequence is set for an event without one")
+	}
+
+	product := &pb.Product{}
+	if err := protojson.Unmarshal(got["data"], product); err != nil {
+		t.Fatalf("failed to unmarshal data: %v", err)
+	}
+	if !proto.Equal(product, testEvent.Data) {
+		t.Errorf("data = %v, want %v", product, testEvent.Data)
+	}
+}
+
+func TestTypeFor(t *testing.T) {
+	tests := []struct {
+		eventType string
+		want      string
+	}{
+		{eventType: CreateProduct, want: "com.github.yaninyzwitty.products.product.created"},
+		{eventType: UpdateCategory, want: "com.github.yaninyzwitty.products.category.updated"},
+		{eventType: DeleteProduct, want: "com.github.yaninyzwitty.products.product.deleted"},
+		{eventType: "RESTOCK", want: "com.github.yaninyzwitty.products.restock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			if got := TypeFor(tt.eventType); got != tt.want {
+				t.Errorf("TypeFor(%q) = %q, want %q", tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
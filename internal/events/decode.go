@@ -0,0 +1,51 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Decode reverses Encode for messages carrying a product event, whatever
+// mode and format they were published with. The event id falls back to
+// the CloudEvents id for events that were encoded without one.
+func Decode(payload []byte, properties map[string]string) (*pb.ProductEvent, error) {
+	var (
+		event pb.ProductEvent
+		id    string
+		err   error
+	)
+
+	switch contentType := properties[PropertyContentType]; contentType {
+	case ContentTypeCloudEvent:
+		var env envelope
+		if err := json.Unmarshal(payload, &env); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal event envelope: %w", err)
+		}
+		id = env.Id
+		err = protojson.Unmarshal(env.Data, &event)
+	case ContentTypeJSON:
+		id = properties[binaryPropertyPrefix+"id"]
+		err = protojson.Unmarshal(payload, &event)
+	case ContentTypeProtobuf, "":
+		id = properties[binaryPropertyPrefix+"id"]
+		err = proto.Unmarshal(payload, &event)
+	default:
+		return nil, fmt.Errorf("unsupported event content type %q", contentType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal event data: %w", err)
+	}
+
+	if event.EventId == "" {
+		event.EventId = id
+	}
+	if event.EventId == "" {
+		return nil, errors.New("event has no id")
+	}
+	return &event, nil
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDecode(t *testing.T) {
+	event := &pb.ProductEvent{
+		Sequence: 2,
+		Event: &pb.ProductEvent_ProductUpdated{
+			ProductUpdated: &pb.ProductUpdated{Product: &pb.Product{Id: 7, Name: "lamp", CategoryId: 1}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		cfg  Config
+		// eventID is the id inside the event data, the CloudEvents id is
+		// used when it is empty
+		eventID string
+		wantID  string
+	}{
+		{name: "binary protobuf", cfg: Config{Mode: ModeBinary, Format: FormatProtobuf}, eventID: "e1", wantID: "e1"},
+		{name: "binary json", cfg: Config{Mode: ModeBinary, Format: FormatJSON}, eventID: "e1", wantID: "e1"},
+		{name: "structured json", cfg: Config{Mode: ModeStructured, Format: FormatJSON}, eventID: "e1", wantID: "e1"},
+		{name: "id from the envelope", cfg: Config{Mode: ModeStructured, Format: FormatJSON}, wantID: "ce1"},
+		{name: "id from the properties", cfg: Config{Mode: ModeBinary, Format: FormatProtobuf}, wantID: "ce1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder, err := NewEncoder(&tt.cfg)
+			if err != nil {
+				t.Fatalf("failed to create encoder: %v", err)
+			}
+			data := proto.Clone(event).(*pb.ProductEvent)
+			data.EventId = tt.eventID
+
+			msg, err := encoder.Encode(Event{Id: "ce1", Type: TypeFor(UpdateProduct), Subject: "7", Data: data})
+			if err != nil {
+				t.Fatalf("failed to encode event: %v", err)
+			}
+
+			got, err := Decode(msg.Payload, msg.Properties)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if got.EventId != tt.wantID {
+				t.Errorf("event id = %q, want %q", got.EventId, tt.wantID)
+			}
+			got.EventId = tt.eventID
+			if !proto.Equal(got, data) {
+				t.Errorf("Decode() = %v, want %v", got, data)
+			}
+		})
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    []byte
+		properties map[string]string
+	}{
+		{name: "unsupported content type", payload: []byte("<event/>"), properties: map[string]string{PropertyContentType: "application/xml"}},
+		{name: "malformed envelope", payload: []byte("{"), properties: map[string]string{PropertyContentType: ContentTypeCloudEvent}},
+		{name: "malformed protobuf", payload: []byte{0xff}, properties: map[string]string{PropertyContentType: ContentTypeProtobuf}},
+		{name: "no id", payload: nil, properties: map[string]string{PropertyContentType: ContentTypeProtobuf}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if event, err := Decode(tt.payload, tt.properties); err == nil {
+				t.Errorf("Decode() = %v, want an error", event)
+			}
+		})
+	}
+}
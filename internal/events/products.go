@@ -0,0 +1,59 @@
+package events
+
+import (
+	"strconv"
+
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+)
+
+// Outbox event types.
+const (
+	CreateProduct  = "CREATE_PRODUCT"
+	UpdateProduct  = "UPDATE_PRODUCT"
+	DeleteProduct  = "DELETE_PRODUCT"
+	CreateCategory = "CREATE_CATEGORY"
+	UpdateCategory = "UPDATE_CATEGORY"
+	DeleteCategory = "DELETE_CATEGORY"
+)
+
+// EventType returns the outbox event type of a product event.
+func EventType(event *pb.ProductEvent) string {
+	switch event.Event.(type) {
+	case *pb.ProductEvent_ProductCreated:
+		return CreateProduct
+	case *pb.ProductEvent_ProductUpdated:
+		return UpdateProduct
+	case *pb.ProductEvent_ProductDeleted:
+		return DeleteProduct
+	case *pb.ProductEvent_CategoryCreated:
+		return CreateCategory
+	case *pb.ProductEvent_CategoryUpdated:
+		return UpdateCategory
+	case *pb.ProductEvent_CategoryDeleted:
+		return DeleteCategory
+	default:
+		return ""
+	}
+}
+
+// Subject returns the id of the product or category the event is about.
+func Subject(event *pb.ProductEvent) string {
+	var id int64
+	switch e := event.Event.(type) {
+	case *pb.ProductEvent_ProductCreated:
+		id = e.ProductCreated.GetProduct().GetId()
+	case *pb.ProductEvent_ProductUpdated:
+		id = e.ProductUpdated.GetProduct().GetId()
+	case *pb.ProductEvent_ProductDeleted:
+		id = e.ProductDeleted.GetProductId()
+	case *pb.ProductEvent_CategoryCreated:
+		id = e.CategoryCreated.GetCategory().GetId()
+	case *pb.ProductEvent_CategoryUpdated:
+		id = e.CategoryUpdated.GetCategory().GetId()
+	case *pb.ProductEvent_CategoryDeleted:
+		id = e.CategoryDeleted.GetCategoryId()
+	default:
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
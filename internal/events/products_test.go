@@ -0,0 +1,108 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+)
+
+func TestEventType(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *pb.ProductEvent
+		want  string
+	}{
+		{
+			name:  "product created",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_ProductCreated{ProductCreated: &pb.ProductCreated{}}},
+			want:  CreateProduct,
+		},
+		{
+			name:  "product updated",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_ProductUpdated{ProductUpdated: &pb.ProductUpdated{}}},
+			want:  UpdateProduct,
+		},
+		{
+			name:  "product deleted",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_ProductDeleted{ProductDeleted: &pb.ProductDeleted{}}},
+			want:  DeleteProduct,
+		},
+		{
+			name:  "category created",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_CategoryCreated{CategoryCreated: &pb.CategoryCreated{}}},
+			want:  CreateCategory,
+		},
+		{
+			name:  "category updated",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_CategoryUpdated{CategoryUpdated: &pb.CategoryUpdated{}}},
+			want:  UpdateCategory,
+		},
+		{
+			name:  "category deleted",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_CategoryDeleted{CategoryDeleted: &pb.CategoryDeleted{}}},
+			want:  DeleteCategory,
+		},
+		{name: "no event", event: &pb.ProductEvent{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EventType(tt.event); got != tt.want {
+				t.Errorf("EventType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubject(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *pb.ProductEvent
+		want  string
+	}{
+		{
+			name: "product created",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_ProductCreated{
+				ProductCreated: &pb.ProductCreated{Product: &pb.Product{Id: 7, CategoryId: 1}},
+			}},
+			want: "7",
+		},
+		{
+			name: "product updated",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_ProductUpdated{
+				ProductUpdated: &pb.ProductUpdated{Product: &pb.Product{Id: 7, CategoryId: 1}},
+			}},
+			want: "7",
+		},
+		{
+			name: "product deleted",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_ProductDeleted{
+				ProductDeleted: &pb.ProductDeleted{ProductId: 7, CategoryId: 1},
+			}},
+			want: "7",
+		},
+		{
+			name: "category created",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_CategoryCreated{
+				CategoryCreated: &pb.CategoryCreated{Category: &pb.Category{Id: 1}},
+			}},
+			want: "1",
+		},
+		{
+			name: "category deleted",
+			event: &pb.ProductEvent{Event: &pb.ProductEvent_CategoryDeleted{
+				CategoryDeleted: &pb.CategoryDeleted{CategoryId: 1},
+			}},
+			want: "1",
+		},
+		{name: "no event", event: &pb.ProductEvent{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Subject(tt.event); got != tt.want {
+				t.Errorf("Subject() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ProducerSchema returns the Pulsar schema describing what an encoder built
+// from cfg emits, so it can be registered and checked on the products topic.
+func ProducerSchema(cfg *Config) (pulsar.Schema, error) {
+	e, err := NewEncoder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return e.Schema()
+}
+
+// Schema returns the Pulsar schema of the encoder's payloads: protobuf native
+// for protobuf data, otherwise a JSON schema with an Avro definition of the
+// protojson event, wrapped in the envelope in structured mode.
+func (e *Encoder) Schema() (pulsar.Schema, error) {
+	if e.format == FormatProtobuf {
+		return pulsar.NewProtoNativeSchemaWithMessage(&pb.ProductEvent{}, nil), nil
+	}
+
+	def := avroRecord((&pb.ProductEvent{}).ProtoReflect().Descriptor(), map[protoreflect.FullName]bool{})
+	if e.mode == ModeStructured {
+		def = envelopeRecord(def)
+	}
+
+	data, err := json.Marshal(def)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event schema: %w", err)
+	}
+	return pulsar.NewJSONSchemaWithValidation(string(data), nil)
+}
+
+// envelopeRecord is the Avro definition of the structured-mode envelope.
+func envelopeRecord(data any) map[string]any {
+	field := func(name string, typ any) map[string]any {
+		return map[string]any{"name": name, "type": typ}
+	}
+	return map[string]any{
+		"type":      "record",
+		"name":      "CloudEvent",
+		"namespace": "io.cloudevents",
+		"fields": []any{
+			field("specversion", "string"),
+			field("id", "string"),
+			field("source", "string"),
+			field("type", "string"),
+			map[string]any{"name": "subject", "type": []any{"null", "string"}, "default": nil},
+			field("time", "string"),
+			map[string]any{"name": "sequence", "type": []any{"null", "string"}, "default": nil},
+			map[string]any{"name": "deliveryguarantee", "type": []any{"null", "string"}, "default": nil},
+			map[string]any{"name": "replay", "type": []any{"null", "boolean"}, "default": nil},
+			field("datacontenttype", "string"),
+			field("data", data),
+		},
+	}
+}
+
+// avroRecord maps a message to the Avro record matching its protojson form.
+// Records already emitted are referenced by name, as Avro requires.
+func avroRecord(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) any {
+	if seen[md.FullName()] {
+		return string(md.FullName())
+	}
+	seen[md.FullName()] = true
+
+	fields := []any{}
+	for i := 0; i < md.Fields().Len(); i++ {
+		fd := md.Fields().Get(i)
+		fields = append(fields, map[string]any{
+			"name":    fd.JSONName(),
+			"type":    []any{"null", avroField(fd, seen)},
+			"default": nil,
+		})
+	}
+
+	return map[string]any{
+		"type":      "record",
+		"name":      string(md.Name()),
+		"namespace": string(md.ParentFile().Package()),
+		"fields":    fields,
+	}
+}
+
+func avroField(fd protoreflect.FieldDescriptor, seen map[protoreflect.FullName]bool) any {
+	if fd.IsMap() {
+		return map[string]any{"type": "map", "values": avroValue(fd.MapValue(), seen)}
+	}
+	if fd.IsList() {
+		return map[string]any{"type": "array", "items": avroValue(fd, seen)}
+	}
+	return avroValue(fd, seen)
+}
+
+// avroValue follows protojson: 64-bit integers, enums, bytes and timestamps
+// are all rendered as strings.
+func avroValue(fd protoreflect.FieldDescriptor, seen map[protoreflect.FullName]bool) any {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return "boolean"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "long"
+	case protoreflect.FloatKind:
+		return "float"
+	case protoreflect.DoubleKind:
+		return "double"
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if fd.Message().FullName() == "google.protobuf.Timestamp" {
+			return "string"
+		}
+		return avroRecord(fd.Message(), seen)
+	default:
+		return "string"
+	}
+}
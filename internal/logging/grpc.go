@@ -0,0 +1,143 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"log/slog"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from incoming requests.
+const (
+	// RequestIDKey carries the request id, taken from the caller when set and
+	// returned in the response header either way.
+	RequestIDKey = "x-request-id"
+	// TenantKey names the tenant the caller acts for.
+	TenantKey = "x-tenant-id"
+)
+
+const (
+	// maxRequestIDLength bounds request ids taken from callers.
+	maxRequestIDLength = 128
+
+	// healthService is the method prefix of the health checks probes poll.
+	healthService = "/grpc.health.v1.Health/"
+)
+
+// UnaryServerInterceptor gives every unary RPC a request id and a logger
+// carrying it, and logs the outcome once the RPC is handled.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		ctx, logger := newRequestLogger(ctx, info.FullMethod)
+
+		resp, err := handler(ctx, req)
+		logOutcome(ctx, logger, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor gives every streaming RPC a request id and a
+// logger carrying it, and logs the outcome once the stream ends.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, logger := newRequestLogger(stream.Context(), info.FullMethod)
+
+		err := handler(srv, &serverStream{ServerStream: stream, ctx: ctx})
+		logOutcome(ctx, logger, info.FullMethod, start, err)
+		return err
+	}
+}
+
+// serverStream overrides the context of a stream with the request's.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// newRequestLogger assigns the request id, sends it back in the response
+// header and returns ctx carrying a logger with the request's attributes.
+func newRequestLogger(ctx context.Context, method string) (context.Context, *slog.Logger) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	requestID := firstValue(md, RequestIDKey)
+	if !validRequestID(requestID) {
+		requestID = rand.Text()
+	}
+	// Fails only when the header was already sent, which cannot happen yet
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, requestID))
+
+	attrs := []any{"request_id", requestID, "method", method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		attrs = append(attrs, "peer", p.Addr.String())
+	}
+	if tenant := firstValue(md, TenantKey); tenant != "" {
+		attrs = append(attrs, "tenant", tenant)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		attrs = append(attrs, "trace_id", spanContext.TraceID().String())
+	}
+
+	logger := slog.Default().With(attrs...)
+	return WithLogger(ctx, logger), logger
+}
+
+// logOutcome logs the status code and duration of the RPC, at error level
+// when the server failed, at debug level for passing health checks and at
+// info level otherwise.
+func logOutcome(ctx context.Context, logger *slog.Logger, method string, start time.Time, err error) {
+	code := status.Code(err)
+	attrs := []slog.Attr{
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(start)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", status.Convert(err).Message()))
+	}
+
+	level := slog.LevelInfo
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded, codes.Unimplemented:
+		level = slog.LevelError
+	case codes.OK:
+		if strings.HasPrefix(method, healthService) {
+			level = slog.LevelDebug
+		}
+	}
+	logger.LogAttrs(ctx, level, "request handled", attrs...)
+}
+
+func firstValue(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// validRequestID accepts ids of printable ASCII that fit in a log line, so a
+// caller cannot forge log records through them.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,47 @@
+// Package logging builds the service's slog handlers and carries a
+// request-scoped logger in the context, so every line logged while serving a
+// request can be correlated by its request id.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Formats, as set in the config.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// NewHandler returns a handler writing records at or above level to w in the
+// given format.
+func NewHandler(w io.Writer, format string, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case "", FormatText:
+		return slog.NewTextHandler(w, opts), nil
+	case FormatJSON:
+		return slog.NewJSONHandler(w, opts), nil
+	default:
+		return nil, fmt.Errorf("log format must be text or json, got %q", format)
+	}
+}
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying the logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of the request ctx belongs to, or the
+// default logger outside of a request.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "products_cassandra_query_duration_seconds",
+		Help:    "Latency of Cassandra queries and batches, by statement.",
+		Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"statement"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "products_cassandra_query_errors_total",
+		Help: "Failed Cassandra query and batch attempts, by statement.",
+	}, []string{"statement"})
+
+	// statementTable finds the table a CQL statement works on.
+	statementTable = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE|TRUNCATE)\s+([\w.]+)`)
+)
+
+// CassandraObserver records the latency and errors of every query and batch
+// attempt, labelled by the statement kind and table, e.g. SELECT chat.products.
+type CassandraObserver struct{}
+
+func (CassandraObserver) ObserveQuery(_ context.Context, q gocql.ObservedQuery) {
+	observeStatement(statementName(q.Statement), q.End.Sub(q.Start).Seconds(), q.Err)
+}
+
+func (CassandraObserver) ObserveBatch(_ context.Context, b gocql.ObservedBatch) {
+	var names []string
+	for _, statement := range b.Statements {
+		if name := statementName(statement); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	observeStatement("BATCH "+strings.Join(names, ", "), b.End.Sub(b.Start).Seconds(), b.Err)
+}
+
+func observeStatement(name string, seconds float64, err error) {
+	queryDuration.WithLabelValues(name).Observe(seconds)
+	if err != nil {
+		queryErrors.WithLabelValues(name).Inc()
+	}
+}
+
+// statementName reduces a statement to its verb and table, keeping the
+// label set small whatever the statement's columns and conditions.
+func statementName(statement string) string {
+	verb := "UNKNOWN"
+	if fields := strings.Fields(statement); len(fields) > 0 {
+		verb = strings.ToUpper(fields[0])
+	}
+
+	match := statementTable.FindStringSubmatch(statement)
+	if match == nil {
+		return verb
+	}
+	return verb + " " + strings.ToLower(match[1])
+}
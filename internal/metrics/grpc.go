@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "products_grpc_server_handling_seconds",
+		Help:    "Latency of gRPC requests handled by the server.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"service", "method", "code"})
+
+	rpcHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "products_grpc_server_handled_total",
+		Help: "gRPC requests handled by the server, by status code.",
+	}, []string{"service", "method", "code"})
+)
+
+// UnaryServerInterceptor records the latency and status code of unary RPCs.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observeRPC(info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the duration and status code of streaming RPCs.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, stream)
+		observeRPC(info.FullMethod, start, err)
+		return err
+	}
+}
+
+func observeRPC(fullMethod string, start time.Time, err error) {
+	service, method := splitMethod(fullMethod)
+	code := status.Code(err).String()
+
+	rpcDuration.WithLabelValues(service, method, code).Observe(time.Since(start).Seconds())
+	rpcHandled.WithLabelValues(service, method, code).Inc()
+}
+
+// splitMethod splits /package.Service/Method into the service and method names.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", fullMethod
+	}
+	return service, method
+}
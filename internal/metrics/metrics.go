@@ -0,0 +1,46 @@
+// Package metrics exposes the service's Prometheus metrics: gRPC server
+// interceptors, gocql query observers and the /metrics endpoint. Packages
+// owning other metrics, such as the outbox relay, register them with the
+// default registry themselves.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Handler serves the metrics of the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Serve exposes /metrics on the port until ctx is done.
+func Serve(ctx context.Context, port int) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Handler())
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("Starting metrics endpoint", "port", port)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+// DefaultShards is the shard count of new windows when none is configured.
+const DefaultShards = 8
+
+// BucketAssigner picks the outbox bucket of new events. Every window keeps
+// the shard count it was first written with, so a changed shard count takes
+// effect with the next window and the relay always scans every shard that
+// may hold events.
+type BucketAssigner struct {
+	store  store.OutboxStore
+	shards int
+
+	mu sync.Mutex
+	// windows caches the shard count of the windows claimed so far.
+	windows map[string]int
+}
+
+// NewBucketAssigner initializes and returns a BucketAssigner spreading new
+// windows over the given number of shards.
+func NewBucketAssigner(shards int, outbox store.OutboxStore) *BucketAssigner {
+	if shards <= 0 {
+		shards = DefaultShards
+	}
+	return &BucketAssigner{
+		store:   outbox,
+		shards:  shards,
+		windows: make(map[string]int),
+	}
+}
+
+// Assign returns the bucket of an event about subject written at t.
+func (a *BucketAssigner) Assign(ctx context.Context, t time.Time, subject string) (string, error) {
+	window := store.WindowFor(t)
+
+	shards, err := a.windowShards(ctx, window)
+	if err != nil {
+		return "", err
+	}
+	return store.BucketFor(window, store.ShardFor(subject, shards)), nil
+}
+
+// windowShards returns the shard count of the window, claiming it with the
+// configured count on the first write.
+func (a *BucketAssigner) windowShards(ctx context.Context, window string) (int, error) {
+	a.mu.Lock()
+	shards, ok := a.windows[window]
+	a.mu.Unlock()
+	if ok {
+		return shards, nil
+	}
+
+	shards, err := a.store.ClaimOutboxShards(ctx, window, a.shards)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim shards of window %s: %w", window, err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Older windows are rarely written to anymore, a late write claims again
+	for cached := range a.windows {
+		if cached < window {
+			delete(a.windows, cached)
+		}
+	}
+	a.windows[window] = shards
+	return shards, nil
+}
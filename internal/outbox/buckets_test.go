@@ -0,0 +1,51 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+func TestBucketAssignerAssign(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	window := store.WindowFor(testNow)
+
+	// a replica with the old shard count claimed the window first
+	if _, err := s.ClaimOutboxShards(ctx, window, 2); err != nil {
+		t.Fatalf("failed to claim shards: %v", err)
+	}
+	assigner := NewBucketAssigner(16, s)
+
+	tests := []struct {
+		name       string
+		subject    string
+		wantBucket string
+	}{
+		{name: "claimed window keeps its shards", subject: "7", wantBucket: store.BucketFor(window, store.ShardFor("7", 2))},
+		{name: "same subject same bucket", subject: "7", wantBucket: store.BucketFor(window, store.ShardFor("7", 2))},
+		{name: "other subject", subject: "8", wantBucket: store.BucketFor(window, store.ShardFor("8", 2))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, err := assigner.Assign(ctx, testNow, tt.subject)
+			if err != nil {
+				t.Fatalf("Assign() error = %v", err)
+			}
+			if bucket != tt.wantBucket {
+				t.Errorf("Assign() = %q, want %q", bucket, tt.wantBucket)
+			}
+		})
+	}
+
+	// the next window is claimed with the configured count
+	next := testNow.Add(store.Window)
+	if _, err := assigner.Assign(ctx, next, "7"); err != nil {
+		t.Fatalf("Assign() error = %v", err)
+	}
+	if shards, err := s.GetOutboxShards(ctx, store.WindowFor(next)); err != nil || shards != 16 {
+		t.Errorf("shards of the next window = %d, %v, want 16", shards, err)
+	}
+}
@@ -0,0 +1,127 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/publisher"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+// txnBatch holds the events published in the open transaction of a bucket;
+// they are deleted from the outbox only once it commits.
+type txnBatch struct {
+	txn      publisher.Transaction
+	events   []store.OutboxEvent
+	subjects []string
+}
+
+func (b *txnBatch) add(message store.OutboxEvent, subject string) {
+	b.events = append(b.events, message)
+	b.subjects = append(b.subjects, subject)
+}
+
+func (b *txnBatch) reset() {
+	b.txn = nil
+	b.events = b.events[:0]
+	b.subjects = b.subjects[:0]
+}
+
+// heldSubjects are the subjects with an event left pending during a run,
+// shared by the shards processed in parallel.
+type heldSubjects struct {
+	mu       sync.Mutex
+	subjects map[string]bool
+}
+
+func newHeldSubjects() *heldSubjects {
+	return &heldSubjects{subjects: make(map[string]bool)}
+}
+
+func (h *heldSubjects) has(subject string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.subjects[subject]
+}
+
+func (h *heldSubjects) hold(subject string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.subjects[subject] = true
+}
+
+// commitBatch commits the open transaction and deletes its events. Events of
+// a failed commit stay in the outbox and get new sequence ids on the next run.
+func (r *Relay) commitBatch(ctx context.Context, batch *txnBatch) error {
+	defer batch.reset()
+
+	if err := batch.txn.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, message := range batch.events {
+		if err := r.deleteMessage(ctx, message); err != nil {
+			return fmt.Errorf("failed to delete message %s: %w", message.Id, err)
+		}
+	}
+	return nil
+}
+
+// abortBatch aborts the open transaction and holds the subjects of its events,
+// which stay in the outbox, returning how many there were.
+func (r *Relay) abortBatch(ctx context.Context, batch *txnBatch, held *heldSubjects) int {
+	defer batch.reset()
+
+	if err := batch.txn.Abort(ctx); err != nil {
+		slog.Warn("failed to abort transaction, it expires on its own", "error", err)
+	}
+
+	for _, subject := range batch.subjects {
+		if subject != "" {
+			held.hold(subject)
+		}
+	}
+	return len(batch.events)
+}
+
+// reserveSequence gives the event a sequence id of its topic and stores it
+// before the first publish. A stored id is reused only while it is above
+// every id the broker acknowledged or this relay sent, so a replay after a
+// crash is dropped by the broker only if the broker got it. Fresh ids are
+// taken above the topic's reservation mark, so they never collide with an
+// id still stored on another pending event; an event whose id is no longer
+// usable may be published twice but is never dropped.
+func (r *Relay) reserveSequence(ctx context.Context, message *store.OutboxEvent) error {
+	topic, brokerLast, err := r.sequencer.LastSequenceID(ctx, message.EventType)
+	if err != nil {
+		return err
+	}
+
+	reserved, ok := r.reserved[topic]
+	if !ok {
+		reserved, err = r.store.GetReservedSequence(ctx, topic)
+		if err != nil {
+			return err
+		}
+		r.reserved[topic] = reserved
+	}
+	sent := max(r.lastSequence[topic], brokerLast)
+
+	if message.PublishSequence > sent {
+		r.lastSequence[topic] = message.PublishSequence
+		r.reserved[topic] = max(reserved, message.PublishSequence)
+		return nil
+	}
+
+	message.PublishSequence = max(reserved, sent, 0) + 1
+	if err := r.store.AssignPublishSequence(ctx, *message, topic); err != nil {
+		return fmt.Errorf("failed to reserve sequence id: %w", err)
+	}
+	r.lastSequence[topic] = message.PublishSequence
+	r.reserved[topic] = message.PublishSequence
+	return nil
+}
@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+var (
+	pendingEvents = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "products_outbox_pending_events",
+		Help: "Outbox events left unpublished after the last relay run, including events waiting for a retry.",
+	})
+
+	oldestPendingAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "products_outbox_oldest_pending_age_seconds",
+		Help: "Time since the start of the oldest bucket holding a pending event, 0 when the outbox is drained.",
+	})
+
+	publishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "products_outbox_publish_duration_seconds",
+		Help:    "Latency of publishing outbox events to the broker, by event type.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"event_type"})
+
+	publishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "products_outbox_publish_failures_total",
+		Help: "Outbox events the broker failed to accept, by event type.",
+	}, []string{"event_type"})
+)
+
+// recordBacklog updates the backlog gauges after a relay run.
+func recordBacklog(pending int, oldestBucket string, now time.Time) {
+	pendingEvents.Set(float64(pending))
+
+	start, ok := bucketStart(oldestBucket)
+	if !ok {
+		oldestPendingAge.Set(0)
+		return
+	}
+	oldestPendingAge.Set(max(now.Sub(start).Seconds(), 0))
+}
+
+// bucketStart returns when a window bucket, or a legacy daily bucket, starts.
+func bucketStart(bucket string) (time.Time, bool) {
+	if bucket == "" {
+		return time.Time{}, false
+	}
+	window, _, _ := strings.Cut(bucket, "#")
+	if start, err := store.ParseWindow(window); err == nil {
+		return start, true
+	}
+	if start, err := store.ParseLegacyBucket(bucket); err == nil {
+		return start, true
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,617 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/events"
+	"github.com/yaninyzwitty/grpc-products-service/internal/publisher"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/internal/tracing"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// DefaultLookback is how far back the relay starts when no checkpoint was saved yet.
+	DefaultLookback = 7 * 24 * time.Hour
+
+	// DefaultMaxAttempts is how many times an event is published before it is dead-lettered.
+	DefaultMaxAttempts = 10
+
+	DefaultRetryBackoff    = 4 * time.Second
+	DefaultMaxRetryBackoff = 10 * time.Minute
+
+	DefaultTransactionTimeout = time.Minute
+	// DefaultTransactionBatchSize caps how many events one transaction publishes.
+	DefaultTransactionBatchSize = 100
+
+	// closeGrace is how long after midnight a bucket is still considered open,
+	// so writes that raced the day boundary are not skipped.
+	closeGrace = 5 * time.Minute
+)
+
+// RelayConfig holds the configuration for the outbox relay.
+type RelayConfig struct {
+	Lookback        time.Duration
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	CloudEvents     events.Config
+
+	// Deduplication reserves a producer sequence id per outbox row so broker
+	// deduplication drops replays. The publisher must implement
+	// publisher.Sequencer, for Pulsar producers must be named and
+	// deduplication enabled on the namespace.
+	Deduplication bool
+	// Transactions publishes the events of a bucket in transactions of up to
+	// TransactionBatchSize events, visible to consumers all at once. The
+	// publisher must implement publisher.Transactional.
+	Transactions         bool
+	TransactionTimeout   time.Duration
+	TransactionBatchSize int
+
+	// ReplayRate caps how many replayed events are published per second.
+	ReplayRate float64
+}
+
+// Relay publishes outbox events and deletes them once acknowledged.
+type Relay struct {
+	store     store.OutboxStore
+	publisher publisher.Publisher
+	encoder   *events.Encoder
+	lookback  time.Duration
+	retry     atomic.Pointer[retryPolicy]
+	now       func() time.Time
+
+	sequencer    publisher.Sequencer     // nil unless deduplication is enabled
+	transactions publisher.Transactional // nil unless transactions are enabled
+	txnTimeout   time.Duration
+	txnBatchSize int
+	delivery     string
+	replays      *rateLimiter
+	// lastSequence is the highest sequence id sent per topic by this relay,
+	// reserved the highest id ever reserved per topic, loaded from the store.
+	lastSequence map[string]int64
+	reserved     map[string]int64
+	// sequenceMu serializes reserving and enqueueing across shards, so ids
+	// reach the broker in the order they were reserved; the acknowledgement
+	// is awaited outside of it.
+	sequenceMu sync.Mutex
+
+	mu            sync.RWMutex
+	oldestPending string
+}
+
+// NewRelay initializes and returns a Relay draining the given outbox store
+// into the publisher.
+func NewRelay(cfg *RelayConfig, outbox store.OutboxStore, pub publisher.Publisher) (*Relay, error) {
+	encoder, err := events.NewEncoder(&cfg.CloudEvents)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Relay{
+		store:        outbox,
+		publisher:    pub,
+		encoder:      encoder,
+		lookback:     cfg.Lookback,
+		now:          time.Now,
+		txnTimeout:   cfg.TransactionTimeout,
+		txnBatchSize: cfg.TransactionBatchSize,
+		delivery:     events.DeliveryAtLeastOnce,
+		lastSequence: make(map[string]int64),
+		reserved:     make(map[string]int64),
+	}
+
+	if cfg.Deduplication {
+		sequencer, ok := pub.(publisher.Sequencer)
+		if !ok {
+			return nil, fmt.Errorf("publisher does not support deduplication")
+		}
+		r.sequencer = sequencer
+		r.delivery = events.DeliveryEffectivelyOnce
+	}
+	if cfg.Transactions {
+		transactions, ok := pub.(publisher.Transactional)
+		if !ok {
+			return nil, fmt.Errorf("publisher does not support transactions")
+		}
+		r.transactions = transactions
+	}
+	if r.txnTimeout <= 0 {
+		r.txnTimeout = DefaultTransactionTimeout
+	}
+	if r.txnBatchSize <= 0 {
+		r.txnBatchSize = DefaultTransactionBatchSize
+	}
+
+	replayRate := cfg.ReplayRate
+	if replayRate <= 0 {
+		replayRate = DefaultReplayRate
+	}
+	r.replays = newRateLimiter(replayRate)
+
+	if r.lookback <= 0 {
+		r.lookback = DefaultLookback
+	}
+	r.SetRetryPolicy(cfg.MaxAttempts, cfg.RetryBackoff, cfg.MaxRetryBackoff)
+
+	return r, nil
+}
+
+// retryPolicy is how often and how far apart failed events are retried.
+type retryPolicy struct {
+	maxAttempts     int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+}
+
+// SetRetryPolicy changes how failed events are retried, zero values fall
+// back to the defaults. Events already scheduled keep their next attempt.
+func (r *Relay) SetRetryPolicy(maxAttempts int, retryBackoff, maxRetryBackoff time.Duration) {
+	policy := &retryPolicy{
+		maxAttempts:     maxAttempts,
+		retryBackoff:    retryBackoff,
+		maxRetryBackoff: maxRetryBackoff,
+	}
+	if policy.maxAttempts <= 0 {
+		policy.maxAttempts = DefaultMaxAttempts
+	}
+	if policy.retryBackoff <= 0 {
+		policy.retryBackoff = DefaultRetryBackoff
+	}
+	if policy.maxRetryBackoff < policy.retryBackoff {
+		policy.maxRetryBackoff = max(DefaultMaxRetryBackoff, policy.retryBackoff)
+	}
+	r.retry.Store(policy)
+}
+
+// SetReplayRate changes how many replayed events are published per second.
+func (r *Relay) SetReplayRate(rate float64) {
+	if rate <= 0 {
+		rate = DefaultReplayRate
+	}
+	r.replays.setRate(rate)
+}
+
+// Release gives up the publisher's broker-side identities, such as named
+// producers, so the next leader can claim them; the sequence ids are reloaded
+// from the broker and the store when they reconnect.
+func (r *Relay) Release() {
+	if releaser, ok := r.publisher.(publisher.Releaser); ok {
+		releaser.Release()
+	}
+
+	r.sequenceMu.Lock()
+	clear(r.lastSequence)
+	clear(r.reserved)
+	r.sequenceMu.Unlock()
+
+	// The new leader reports the backlog from now on
+	recordBacklog(0, "", time.Time{})
+}
+
+// OldestPendingBucket returns the oldest bucket that still held unpublished
+// events after the last run, or an empty string if the outbox was drained.
+func (r *Relay) OldestPendingBucket() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.oldestPending
+}
+
+// ProcessMessages walks every window from the saved checkpoint up to the
+// current one, publishes the pending events and advances the checkpoint past
+// windows that are closed and fully drained. The shards of a window are
+// processed in parallel.
+func (r *Relay) ProcessMessages(ctx context.Context) error {
+	now := r.now()
+
+	checkpoint, err := r.store.GetOutboxCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	if checkpoint == "" {
+		checkpoint = store.WindowFor(now.Add(-r.lookback))
+	}
+
+	next := checkpoint
+	first := checkpoint
+	advancing := true
+	oldestPending := ""
+	totalPending := 0
+	// Subjects with an event left pending, their later events wait for it.
+	held := newHeldSubjects()
+
+	// Daily buckets written before the outbox was sharded are drained
+	// before the checkpoint moves on to windows.
+	if day, err := store.ParseLegacyBucket(checkpoint); err == nil {
+		pending, oldest, err := r.processLegacyBuckets(ctx, day, now, held)
+		if err != nil {
+			return err
+		}
+		oldestPending = oldest
+		totalPending += pending
+
+		first = store.WindowFor(day)
+		if pending == 0 && now.After(day.AddDate(0, 0, 1).Add(closeGrace)) {
+			next = first
+		} else {
+			advancing = false
+		}
+	}
+
+	start, err := store.ParseWindow(first)
+	if err != nil {
+		return fmt.Errorf("invalid checkpoint %q: %w", checkpoint, err)
+	}
+
+	current := store.WindowFor(now)
+	for window := first; window <= current; window = store.WindowFor(start) {
+		pending, oldest, err := r.processWindow(ctx, window, now, held)
+		if err != nil {
+			return fmt.Errorf("failed to process window %s: %w", window, err)
+		}
+
+		if pending > 0 && oldestPending == "" {
+			oldestPending = oldest
+		}
+		totalPending += pending
+
+		start = start.Add(store.Window)
+		// Only move past a window once nothing can be written to it anymore.
+		if advancing && pending == 0 && now.After(start.Add(closeGrace)) {
+			next = store.WindowFor(start)
+		} else {
+			advancing = false
+		}
+	}
+
+	r.mu.Lock()
+	r.oldestPending = oldestPending
+	r.mu.Unlock()
+	recordBacklog(totalPending, oldestPending, now)
+
+	if oldestPending != "" {
+		slog.Warn("outbox has pending events", "oldestBucket", oldestPending)
+	}
+
+	if next != checkpoint {
+		if err := r.store.SaveOutboxCheckpoint(ctx, next); err != nil {
+			return fmt.Errorf("failed to save checkpoint: %w", err)
+		}
+		slog.Info("outbox checkpoint advanced", "from", checkpoint, "to", next)
+	}
+
+	return nil
+}
+
+// processLegacyBuckets drains the daily buckets from day up to today and
+// returns how many events are still left and the oldest bucket holding one.
+func (r *Relay) processLegacyBuckets(ctx context.Context, day, now time.Time, held *heldSubjects) (int, string, error) {
+	today := now.Format(store.LegacyBucketLayout)
+
+	total, oldest := 0, ""
+	for bucket := day.Format(store.LegacyBucketLayout); bucket <= today; bucket = day.Format(store.LegacyBucketLayout) {
+		pending, err := r.processBucket(ctx, bucket, now, held)
+		if err != nil {
+			return 0, "", fmt.Errorf("failed to process bucket %s: %w", bucket, err)
+		}
+		if pending > 0 && oldest == "" {
+			oldest = bucket
+		}
+		total += pending
+		day = day.AddDate(0, 0, 1)
+	}
+	return total, oldest, nil
+}
+
+// processWindow processes the shards of the window in parallel and returns
+// how many events are still left and the first bucket holding one.
+func (r *Relay) processWindow(ctx context.Context, window string, now time.Time, held *heldSubjects) (int, string, error) {
+	shards, err := r.store.GetOutboxShards(ctx, window)
+	if err != nil {
+		return 0, "", err
+	}
+
+	pending := make([]int, shards)
+	errs := make([]error, shards)
+
+	var wg sync.WaitGroup
+	for shard := range shards {
+		wg.Go(func() {
+			pending[shard], errs[shard] = r.processBucket(ctx, store.BucketFor(window, shard), now, held)
+		})
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return 0, "", err
+	}
+
+	total, oldest := 0, ""
+	for shard, count := range pending {
+		if count > 0 && oldest == "" {
+			oldest = store.BucketFor(window, shard)
+		}
+		total += count
+	}
+	return total, oldest, nil
+}
+
+// processBucket publishes the events of a single bucket that are due and
+// returns how many are still left in it. Events of a subject in held are
+// skipped so every product is published strictly in outbox order.
+func (r *Relay) processBucket(ctx context.Context, bucket string, now time.Time, held *heldSubjects) (int, error) {
+	// Stop as soon as the run is cancelled, e.g. because leadership was lost
+	if ctx.Err() != nil {
+		return 0, context.Cause(ctx)
+	}
+
+	messages, err := r.store.ListOutboxEvents(ctx, bucket)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch messages: %w", err)
+	}
+
+	pending := 0
+	batch := &txnBatch{}
+	for _, message := range messages {
+		if ctx.Err() != nil {
+			return 0, context.Cause(ctx)
+		}
+
+		event, err := decodeEvent(message)
+		if err != nil {
+			err = fmt.Errorf("failed to unmarshal payload: %w", err)
+		}
+
+		subject := ""
+		if event != nil {
+			subject = events.Subject(event)
+		}
+
+		if subject != "" && held.has(subject) {
+			pending++
+			continue
+		}
+		if message.NextAttemptAt.After(now) {
+			held.hold(subject)
+			pending++
+			continue
+		}
+		// Replays trickle out so they do not swamp the topic
+		if event != nil && event.Replay && !r.replays.allow(r.now()) {
+			held.hold(subject)
+			pending++
+			continue
+		}
+
+		if err == nil {
+			err = r.publish(ctx, batch, &message, event, subject)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, context.Cause(ctx)
+			}
+			slog.Error("Failed to publish message", "error", err, "messageID", message.Id, "bucket", message.Bucket)
+
+			// Nothing in an aborted transaction is visible, so the whole batch is retried.
+			if batch.txn != nil {
+				pending += r.abortBatch(ctx, batch, held)
+			}
+
+			deadLettered, err := r.recordFailure(ctx, message, err, now)
+			if err != nil {
+				return 0, err
+			}
+			if !deadLettered {
+				held.hold(subject)
+				pending++
+			}
+			continue
+		}
+
+		if batch.txn != nil {
+			batch.add(message, subject)
+			if len(batch.events) >= r.txnBatchSize {
+				if err := r.commitBatch(ctx, batch); err != nil {
+					return 0, err
+				}
+			}
+			continue
+		}
+
+		// Delete message from outbox after successful send
+		if err := r.deleteMessage(ctx, message); err != nil {
+			return 0, fmt.Errorf("failed to delete message %s: %w", message.Id, err)
+		}
+	}
+
+	if batch.txn != nil {
+		if err := r.commitBatch(ctx, batch); err != nil {
+			return 0, err
+		}
+	}
+	return pending, nil
+}
+
+// publish sends the event, inside the open transaction of the batch when
+// transactions are enabled, starting one if needed.
+func (r *Relay) publish(ctx context.Context, batch *txnBatch, message *store.OutboxEvent, event *pb.ProductEvent, subject string) error {
+	if r.transactions != nil && batch.txn == nil {
+		txn, err := r.transactions.BeginTransaction(r.txnTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		batch.txn = txn
+	}
+	return r.send(ctx, batch.txn, message, event, subject)
+}
+
+// recordFailure schedules the next attempt of a failed event with
+// exponential backoff, or dead-letters it once the retry budget is spent.
+func (r *Relay) recordFailure(ctx context.Context, message store.OutboxEvent, sendErr error, now time.Time) (bool, error) {
+	message.Attempts++
+	message.LastError = sendErr.Error()
+	// The broker may have seen the id, reusing it could get the retry dropped.
+	message.PublishSequence = 0
+
+	policy := r.retry.Load()
+	if message.Attempts >= policy.maxAttempts {
+		if err := r.store.DeadLetterOutboxEvent(ctx, message); err != nil {
+			return false, fmt.Errorf("failed to dead-letter message %s: %w", message.Id, err)
+		}
+		slog.Warn("Message moved to dead-letter table", "messageID", message.Id, "attempts", message.Attempts, "error", message.LastError)
+		return true, nil
+	}
+
+	message.NextAttemptAt = now.Add(policy.backoff(message.Attempts))
+	if err := r.store.RecordOutboxFailure(ctx, message); err != nil {
+		return false, fmt.Errorf("failed to record failure of message %s: %w", message.Id, err)
+	}
+	return false, nil
+}
+
+// backoff doubles the retry delay with every attempt, up to maxRetryBackoff.
+func (p *retryPolicy) backoff(attempts int) time.Duration {
+	delay := p.retryBackoff
+	for i := 1; i < attempts && delay < p.maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, p.maxRetryBackoff)
+}
+
+// send encodes the event and publishes it keyed by its subject. A nil txn
+// publishes immediately. The publish span continues the trace of the request
+// that wrote the event and its context is sent in the message properties.
+func (r *Relay) send(ctx context.Context, txn publisher.Transaction, message *store.OutboxEvent, event *pb.ProductEvent, subject string) (err error) {
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, message.TraceContext), "publish "+message.EventType,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingOperationTypePublish,
+			semconv.MessagingMessageID(message.Id.String()),
+			attribute.String("outbox.bucket", message.Bucket),
+			attribute.String("outbox.subject", subject),
+			attribute.Int("outbox.attempts", message.Attempts),
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	encoded, err := r.encoder.Encode(events.Event{
+		Id:       message.Id.String(),
+		Type:     events.TypeFor(message.EventType),
+		Subject:  subject,
+		Time:     event.OccurredAt.AsTime(),
+		Sequence: event.Sequence,
+		Delivery: r.delivery,
+		Replay:   event.Replay,
+		Data:     event,
+	})
+	if err != nil {
+		return err
+	}
+
+	properties := encoded.Properties
+	if traceContext := tracing.Inject(ctx); traceContext != nil {
+		if properties == nil {
+			properties = make(map[string]string, len(traceContext))
+		}
+		maps.Copy(properties, traceContext)
+	}
+
+	msg := &publisher.Message{
+		Id:          message.Id.String(),
+		EventType:   message.EventType,
+		Key:         subject,
+		Payload:     encoded.Payload,
+		Properties:  properties,
+		Transaction: txn,
+	}
+
+	start := time.Now()
+	if r.sequencer != nil {
+		err = r.publishSequenced(ctx, message, msg)
+	} else {
+		err = r.publisher.Publish(ctx, msg)
+	}
+	publishDuration.WithLabelValues(message.EventType).Observe(time.Since(start).Seconds())
+	if err != nil {
+		publishFailures.WithLabelValues(message.EventType).Inc()
+		return err
+	}
+	slog.Debug("Message published", "messageID", message.Id, "eventType", message.EventType)
+
+	return nil
+}
+
+// publishSequenced reserves the event's sequence id and enqueues it under
+// sequenceMu, then waits for the acknowledgement without holding it, so
+// shards publishing in parallel keep the ids in order on the wire.
+func (r *Relay) publishSequenced(ctx context.Context, message *store.OutboxEvent, msg *publisher.Message) error {
+	r.sequenceMu.Lock()
+	if err := r.reserveSequence(ctx, message); err != nil {
+		r.sequenceMu.Unlock()
+		return err
+	}
+	msg.SequenceID = message.PublishSequence
+	wait, err := r.sequencer.PublishAsync(ctx, msg)
+	r.sequenceMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return wait()
+}
+
+// decodeEvent reads the binary event of an outbox row. Rows written by older
+// builds carry a JSON product in Payload and are wrapped in a ProductCreated.
+func decodeEvent(message store.OutboxEvent) (*pb.ProductEvent, error) {
+	event := &pb.ProductEvent{}
+	if len(message.Data) > 0 {
+		if err := proto.Unmarshal(message.Data, event); err != nil {
+			return nil, err
+		}
+		return event, nil
+	}
+
+	product := &pb.Product{}
+	if err := protojson.Unmarshal([]byte(message.Payload), product); err != nil {
+		product = &pb.Product{}
+		if err := json.Unmarshal([]byte(message.Payload), product); err != nil {
+			return nil, err
+		}
+	}
+
+	event.EventId = message.Id.String()
+	event.OccurredAt = timestamppb.New(message.Id.Time())
+	event.Event = &pb.ProductEvent_ProductCreated{
+		ProductCreated: &pb.ProductCreated{Product: product},
+	}
+	return event, nil
+}
+
+// deleteMessage removes the event from the bucket it was read from.
+func (r *Relay) deleteMessage(ctx context.Context, message store.OutboxEvent) error {
+	slog.Debug("Deleting message", "messageID", message.Id, "bucket", message.Bucket)
+
+	return r.store.DeleteOutboxEvent(ctx, message.Bucket, message.Id)
+}
@@ -0,0 +1,348 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/internal/publisher"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+// testNow is half past a window, well after the previous one closed.
+var testNow = time.Date(2026, time.October, 19, 12, 30, 0, 0, time.UTC)
+
+// fakePublisher records what it publishes and fails while err is set.
+type fakePublisher struct {
+	mu        sync.Mutex
+	err       error
+	published []*publisher.Message
+	released  int
+}
+
+func (p *fakePublisher) Publish(_ context.Context, msg *publisher.Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return p.err
+	}
+	p.published = append(p.published, msg)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	return nil
+}
+
+func (p *fakePublisher) Release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.released++
+}
+
+func (p *fakePublisher) messages() []*publisher.Message {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*publisher.Message(nil), p.published...)
+}
+
+// productRow returns an outbox row carrying a ProductUpdated event of the
+// product, written at to the single shard of its window.
+func productRow(t *testing.T, at time.Time, productID int64, name string, sequence uint64) store.OutboxEvent {
+	t.Helper()
+
+	id := gocql.UUIDFromTime(at.Add(time.Duration(sequence) * time.Millisecond))
+	event := &pb.ProductEvent{
+		EventId:  id.String(),
+		Sequence: sequence,
+		Event: &pb.ProductEvent_ProductUpdated{
+			ProductUpdated: &pb.ProductUpdated{Product: &pb.Product{Id: productID, Name: name, CategoryId: 1}},
+		},
+	}
+	data, err := proto.Marshal(event)
+	if err != nil {
+		t.Fatalf("failed to marshal event: %v", err)
+	}
+
+	return store.OutboxEvent{
+		Id:        id,
+		Bucket:    store.BucketFor(store.WindowFor(at), 0),
+		EventType: "UPDATE_PRODUCT",
+		Data:      data,
+		Sequence:  sequence,
+	}
+}
+
+// newTestRelay returns a relay running at testNow over a memory store
+// holding rows, with a single shard in every window.
+func newTestRelay(t *testing.T, maxAttempts int, rows ...store.OutboxEvent) (*Relay, *store.MemoryStore, *fakePublisher) {
+	t.Helper()
+
+	s := store.NewMemoryStore()
+	for start := testNow.Add(-DefaultLookback); !start.After(testNow); start = start.Add(store.Window) {
+		if _, err := s.ClaimOutboxShards(context.Background(), store.WindowFor(start), 1); err != nil {
+			t.Fatalf("failed to claim shards: %v", err)
+		}
+	}
+	for _, row := range rows {
+		if err := s.InsertOutboxEvent(context.Background(), row); err != nil {
+			t.Fatalf("failed to insert row: %v", err)
+		}
+	}
+
+	pub := &fakePublisher{}
+	relay, err := NewRelay(&RelayConfig{MaxAttempts: maxAttempts, RetryBackoff: time.Second}, s, pub)
+	if err != nil {
+		t.Fatalf("failed to create relay: %v", err)
+	}
+	relay.now = func() time.Time { return testNow }
+	return relay, s, pub
+}
+
+// publishedNames returns the names of the products the messages carry,
+// checking every message is keyed by its product.
+func publishedNames(t *testing.T, messages []*publisher.Message) []string {
+	t.Helper()
+
+	var names []string
+	for _, msg := range messages {
+		event := &pb.ProductEvent{}
+		if err := proto.Unmarshal(msg.Payload, event); err != nil {
+			t.Fatalf("failed to unmarshal payload: %v", err)
+		}
+		product := event.GetProductUpdated().GetProduct()
+		if product == nil {
+			product = event.GetProductCreated().GetProduct()
+		}
+		if msg.Key != strconv.FormatInt(product.GetId(), 10) {
+			t.Errorf("message key = %q, want product id %d", msg.Key, product.GetId())
+		}
+		names = append(names, product.GetName())
+	}
+	return names
+}
+
+// pendingRows returns every row left in the outbox.
+func pendingRows(t *testing.T, s *store.MemoryStore) []store.OutboxEvent {
+	t.Helper()
+
+	var rows []store.OutboxEvent
+	for start := testNow.Add(-DefaultLookback); !start.After(testNow); start = start.Add(store.Window) {
+		bucket, err := s.ListOutboxEvents(context.Background(), store.BucketFor(store.WindowFor(start), 0))
+		if err != nil {
+			t.Fatalf("failed to list outbox events: %v", err)
+		}
+		rows = append(rows, bucket...)
+	}
+	return rows
+}
+
+func TestRelayProcessMessages(t *testing.T) {
+	publishErr := errors.New("broker unavailable")
+
+	tests := []struct {
+		name        string
+		rows        func(t *testing.T) []store.OutboxEvent
+		checkpoint  string // saved before the run, if set
+		publishErr  error
+		maxAttempts int
+
+		wantPublished   []string // product names, in publish order
+		wantPending     int
+		wantAttempts    []int  // of the pending rows in order, unchecked when nil
+		wantOldest      string // oldest scanned bucket left with events
+		wantCheckpoint  string
+		wantDeadLetters int
+	}{
+		{
+			name:           "empty outbox",
+			rows:           func(*testing.T) []store.OutboxEvent { return nil },
+			wantCheckpoint: store.WindowFor(testNow),
+		},
+		{
+			name: "rows of past windows are drained in order",
+			rows: func(t *testing.T) []store.OutboxEvent {
+				return []store.OutboxEvent{
+					productRow(t, testNow.Add(-3*24*time.Hour), 1, "lamp", 1),
+					productRow(t, testNow.Add(-2*time.Hour), 2, "chair", 1),
+					productRow(t, testNow, 3, "desk", 1),
+				}
+			},
+			wantPublished:  []string{"lamp", "chair", "desk"},
+			wantCheckpoint: store.WindowFor(testNow),
+		},
+		{
+			name: "windows before the checkpoint are not scanned",
+			rows: func(t *testing.T) []store.OutboxEvent {
+				return []store.OutboxEvent{
+					productRow(t, testNow.Add(-3*time.Hour), 1, "lamp", 1),
+					productRow(t, testNow.Add(-time.Hour), 2, "chair", 1),
+				}
+			},
+			checkpoint:     store.WindowFor(testNow.Add(-2 * time.Hour)),
+			wantPublished:  []string{"chair"},
+			wantPending:    1,
+			wantCheckpoint: store.WindowFor(testNow),
+		},
+		{
+			name: "legacy json payload",
+			rows: func(*testing.T) []store.OutboxEvent {
+				return []store.OutboxEvent{{
+					Id:        gocql.UUIDFromTime(testNow),
+					Bucket:    store.BucketFor(store.WindowFor(testNow), 0),
+					EventType: "CREATE_PRODUCT",
+					Payload:   `{"id":"4","name":"shelf","categoryId":"1"}`,
+				}}
+			},
+			wantPublished:  []string{"shelf"},
+			wantCheckpoint: store.WindowFor(testNow),
+		},
+		{
+			name: "undecodable rows are dead-lettered",
+			rows: func(t *testing.T) []store.OutboxEvent {
+				row := productRow(t, testNow, 1, "lamp", 1)
+				row.Data = []byte{0xff}
+				return []store.OutboxEvent{row, productRow(t, testNow, 2, "chair", 2)}
+			},
+			maxAttempts:     1,
+			wantPublished:   []string{"chair"},
+			wantCheckpoint:  store.WindowFor(testNow),
+			wantDeadLetters: 1,
+		},
+		{
+			name: "failed publish is retried later",
+			rows: func(t *testing.T) []store.OutboxEvent {
+				return []store.OutboxEvent{productRow(t, testNow, 1, "lamp", 1)}
+			},
+			publishErr:     publishErr,
+			maxAttempts:    3,
+			wantPending:    1,
+			wantAttempts:   []int{1},
+			wantOldest:     store.BucketFor(store.WindowFor(testNow), 0),
+			wantCheckpoint: store.WindowFor(testNow),
+		},
+		{
+			name: "later events of a failed product wait for it",
+			rows: func(t *testing.T) []store.OutboxEvent {
+				return []store.OutboxEvent{
+					productRow(t, testNow, 1, "lamp", 1),
+					productRow(t, testNow, 1, "floor lamp", 2),
+				}
+			},
+			publishErr:     publishErr,
+			maxAttempts:    3,
+			wantPending:    2,
+			wantAttempts:   []int{1, 0},
+			wantOldest:     store.BucketFor(store.WindowFor(testNow), 0),
+			wantCheckpoint: store.WindowFor(testNow),
+		},
+		{
+			name: "exhausted retries are dead-lettered",
+			rows: func(t *testing.T) []store.OutboxEvent {
+				return []store.OutboxEvent{productRow(t, testNow, 1, "lamp", 1)}
+			},
+			publishErr:      publishErr,
+			maxAttempts:     1,
+			wantCheckpoint:  store.WindowFor(testNow),
+			wantDeadLetters: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relay, s, pub := newTestRelay(t, tt.maxAttempts, tt.rows(t)...)
+			if tt.checkpoint != "" {
+				if err := s.SaveOutboxCheckpoint(context.Background(), tt.checkpoint); err != nil {
+					t.Fatalf("failed to save checkpoint: %v", err)
+				}
+			}
+			pub.err = tt.publishErr
+
+			if err := relay.ProcessMessages(context.Background()); err != nil {
+				t.Fatalf("ProcessMessages() error = %v", err)
+			}
+
+			if names := publishedNames(t, pub.messages()); !slices.Equal(names, tt.wantPublished) {
+				t.Errorf("published %v, want %v", names, tt.wantPublished)
+			}
+
+			pending := pendingRows(t, s)
+			if len(pending) != tt.wantPending {
+				t.Fatalf("%d events pending, want %d", len(pending), tt.wantPending)
+			}
+			for i, attempts := range tt.wantAttempts {
+				event := pending[i]
+				if event.Attempts != attempts {
+					t.Errorf("pending event %d has %d attempts, want %d", i, event.Attempts, attempts)
+				}
+				if attempts > 0 && (event.LastError == "" || !event.NextAttemptAt.After(testNow)) {
+					t.Errorf("pending event %d has error %q, next attempt %v", i, event.LastError, event.NextAttemptAt)
+				}
+			}
+			if got := relay.OldestPendingBucket(); got != tt.wantOldest {
+				t.Errorf("oldest pending bucket = %q, want %q", got, tt.wantOldest)
+			}
+
+			checkpoint, err := s.GetOutboxCheckpoint(context.Background())
+			if err != nil {
+				t.Fatalf("failed to get checkpoint: %v", err)
+			}
+			if checkpoint != tt.wantCheckpoint {
+				t.Errorf("checkpoint = %q, want %q", checkpoint, tt.wantCheckpoint)
+			}
+
+			deadLetters, _, err := s.ListDeadLetters(context.Background(), 10, nil)
+			if err != nil {
+				t.Fatalf("failed to list dead letters: %v", err)
+			}
+			if len(deadLetters) != tt.wantDeadLetters {
+				t.Errorf("%d dead letters, want %d", len(deadLetters), tt.wantDeadLetters)
+			}
+		})
+	}
+}
+
+func TestRelayRetriesAfterBackoff(t *testing.T) {
+	relay, s, pub := newTestRelay(t, 3, productRow(t, testNow, 1, "lamp", 1))
+
+	pub.err = errors.New("broker unavailable")
+	if err := relay.ProcessMessages(context.Background()); err != nil {
+		t.Fatalf("ProcessMessages() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		at            time.Time
+		wantPublished int
+	}{
+		{name: "before the backoff", at: testNow.Add(500 * time.Millisecond), wantPublished: 0},
+		{name: "after the backoff", at: testNow.Add(2 * time.Second), wantPublished: 1},
+	}
+
+	pub.err = nil
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relay.now = func() time.Time { return tt.at }
+			if err := relay.ProcessMessages(context.Background()); err != nil {
+				t.Fatalf("ProcessMessages() error = %v", err)
+			}
+			if got := len(pub.messages()); got != tt.wantPublished {
+				t.Errorf("published %d messages, want %d", got, tt.wantPublished)
+			}
+		})
+	}
+
+	if pending := pendingRows(t, s); len(pending) != 0 {
+		t.Errorf("%d events pending after the retry, want 0", len(pending))
+	}
+}
@@ -0,0 +1,319 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/internal/events"
+	"github.com/yaninyzwitty/grpc-products-service/internal/logging"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/internal/tracing"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultReplayRate is how many replayed events the relay publishes per second.
+const DefaultReplayRate = 50
+
+// maxReplayJobs bounds how many replay jobs are remembered, the oldest
+// finished ones are forgotten first.
+const maxReplayJobs = 100
+
+// States of a replay job.
+const (
+	ReplayRunning = "running"
+	ReplayDone    = "done"
+	ReplayFailed  = "failed"
+)
+
+// ErrReplayRunning is returned when a replay is started while another one is
+// still running.
+var ErrReplayRunning = errors.New("a replay is already running")
+
+// ReplayFilter selects the categories and products whose events are
+// replayed. A zero CategoryID and ProductID select everything; From and To
+// bound the time a row last changed when set.
+type ReplayFilter struct {
+	CategoryID int64
+	ProductID  int64 // requires CategoryID
+	From       time.Time
+	To         time.Time
+}
+
+func (f ReplayFilter) includes(changedAt time.Time) bool {
+	if !f.From.IsZero() && changedAt.Before(f.From) {
+		return false
+	}
+	return f.To.IsZero() || changedAt.Before(f.To)
+}
+
+// ReplayJob is a snapshot of a replay running in the background.
+type ReplayJob struct {
+	ID         string
+	State      string // ReplayRunning, ReplayDone or ReplayFailed
+	Enqueued   int
+	Err        error
+	StartedAt  time.Time
+	FinishedAt time.Time
+}
+
+// replayJob is a replay job whose progress is updated as it runs.
+type replayJob struct {
+	id        string
+	startedAt time.Time
+	enqueued  atomic.Int64
+
+	mu         sync.Mutex
+	state      string
+	err        error
+	finishedAt time.Time
+}
+
+func (j *replayJob) snapshot() ReplayJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return ReplayJob{
+		ID:         j.id,
+		State:      j.state,
+		Enqueued:   int(j.enqueued.Load()),
+		Err:        j.err,
+		StartedAt:  j.startedAt,
+		FinishedAt: j.finishedAt,
+	}
+}
+
+func (j *replayJob) finish(err error, now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.state = ReplayDone
+	if err != nil {
+		j.state = ReplayFailed
+		j.err = err
+	}
+	j.finishedAt = now
+}
+
+// Replayer regenerates events from the current state of categories and
+// products and writes them to the outbox, flagged as replays, for the relay
+// to publish at its replay rate. Replays run in the background one at a
+// time; their jobs live in memory and are lost when the process exits.
+type Replayer struct {
+	store   store.ReplayStore
+	buckets *BucketAssigner
+	now     func() time.Time
+
+	mu      sync.Mutex
+	jobs    map[string]*replayJob
+	order   []string // job ids, oldest first
+	running *replayJob
+}
+
+// NewReplayer initializes and returns a Replayer writing to the buckets
+// picked by the assigner.
+func NewReplayer(replayStore store.ReplayStore, buckets *BucketAssigner) *Replayer {
+	return &Replayer{
+		store:   replayStore,
+		buckets: buckets,
+		now:     time.Now,
+		jobs:    make(map[string]*replayJob),
+	}
+}
+
+// Start runs the replay of the filter in the background and returns its
+// job, or ErrReplayRunning while another replay runs. The replay keeps the
+// logger and trace of ctx but not its cancellation, so it outlives the
+// request that started it.
+func (r *Replayer) Start(ctx context.Context, filter ReplayFilter) (ReplayJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running != nil {
+		return ReplayJob{}, ErrReplayRunning
+	}
+
+	job := &replayJob{
+		id:        gocql.TimeUUID().String(),
+		startedAt: r.now(),
+		state:     ReplayRunning,
+	}
+	r.running = job
+	r.remember(job)
+
+	go func() {
+		err := r.replay(context.WithoutCancel(ctx), filter, &job.enqueued)
+		job.finish(err, r.now())
+
+		r.mu.Lock()
+		r.running = nil
+		r.mu.Unlock()
+	}()
+
+	return job.snapshot(), nil
+}
+
+// Job returns the replay job with the id, false if it is unknown or was
+// forgotten.
+func (r *Replayer) Job(id string) (ReplayJob, bool) {
+	r.mu.Lock()
+	job, ok := r.jobs[id]
+	r.mu.Unlock()
+
+	if !ok {
+		return ReplayJob{}, false
+	}
+	return job.snapshot(), true
+}
+
+// remember adds the job and forgets the oldest finished jobs beyond
+// maxReplayJobs. Called with mu held.
+func (r *Replayer) remember(job *replayJob) {
+	r.jobs[job.id] = job
+	r.order = append(r.order, job.id)
+
+	for len(r.order) > maxReplayJobs && r.order[0] != r.running.id {
+		delete(r.jobs, r.order[0])
+		r.order = r.order[1:]
+	}
+}
+
+// replay writes a created event for every category and product matching the
+// filter, categories first, counting them in enqueued. Each event carries
+// the sequence of the last change of its row so consumers that are up to
+// date can ignore it.
+func (r *Replayer) replay(ctx context.Context, filter ReplayFilter, enqueued *atomic.Int64) error {
+	enqueue := func(snapshot store.Snapshot) error {
+		event, changedAt := snapshotEvent(snapshot)
+		if !filter.includes(changedAt) {
+			return nil
+		}
+		if err := r.enqueue(ctx, event, snapshot.Sequence, changedAt); err != nil {
+			return err
+		}
+		enqueued.Add(1)
+		return nil
+	}
+
+	var err error
+	switch {
+	case filter.ProductID != 0:
+		var snapshot *store.Snapshot
+		snapshot, err = r.store.GetProductSnapshot(ctx, filter.CategoryID, filter.ProductID)
+		if err == nil {
+			err = enqueue(*snapshot)
+		}
+	case filter.CategoryID != 0:
+		var snapshot *store.Snapshot
+		snapshot, err = r.store.GetCategorySnapshot(ctx, filter.CategoryID)
+		if err == nil {
+			err = enqueue(*snapshot)
+		}
+		if err == nil {
+			err = r.store.ScanProductSnapshots(ctx, filter.CategoryID, enqueue)
+		}
+	default:
+		err = r.store.ScanCategorySnapshots(ctx, enqueue)
+		if err == nil {
+			err = r.store.ScanProductSnapshots(ctx, 0, enqueue)
+		}
+	}
+
+	logging.FromContext(ctx).Info("replay events enqueued", "count", enqueued.Load(), "categoryID", filter.CategoryID, "productID", filter.ProductID, "error", err)
+	return err
+}
+
+func (r *Replayer) enqueue(ctx context.Context, event *pb.ProductEvent, sequence uint64, changedAt time.Time) error {
+	now := r.now()
+
+	bucket, err := r.buckets.Assign(ctx, now, events.Subject(event))
+	if err != nil {
+		return err
+	}
+
+	id := gocql.TimeUUID()
+	event.EventId = id.String()
+	event.OccurredAt = timestamppb.New(changedAt)
+	event.Sequence = sequence
+	event.Replay = true
+
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal replay event: %w", err)
+	}
+
+	return r.store.InsertOutboxEvent(ctx, store.OutboxEvent{
+		Id:           id,
+		Bucket:       bucket,
+		EventType:    events.EventType(event),
+		Data:         data,
+		Sequence:     sequence,
+		TraceContext: tracing.Inject(ctx),
+	})
+}
+
+// snapshotEvent returns the created event describing the snapshot and the
+// time its row last changed.
+func snapshotEvent(snapshot store.Snapshot) (*pb.ProductEvent, time.Time) {
+	if snapshot.Category != nil {
+		return &pb.ProductEvent{
+			Event: &pb.ProductEvent_CategoryCreated{
+				CategoryCreated: &pb.CategoryCreated{Category: snapshot.Category},
+			},
+		}, snapshot.Category.CreatedAt.AsTime()
+	}
+
+	return &pb.ProductEvent{
+		Event: &pb.ProductEvent_ProductCreated{
+			ProductCreated: &pb.ProductCreated{Product: snapshot.Product},
+		},
+	}, snapshot.Product.UpdatedAt.AsTime()
+}
+
+// rateLimiter is a token bucket refilled at rate tokens per second that
+// holds at most one second's worth of tokens, and at least one.
+type rateLimiter struct {
+	mu       sync.Mutex
+	rate     float64
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newRateLimiter(rate float64) *rateLimiter {
+	capacity := max(rate, 1)
+	return &rateLimiter{rate: rate, capacity: capacity, tokens: capacity}
+}
+
+// allow takes a token if one is available.
+func (l *rateLimiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.last.IsZero() {
+		l.tokens = min(l.capacity, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// setRate changes the refill rate, tokens above the new capacity are dropped.
+func (l *rateLimiter) setRate(rate float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rate = rate
+	l.capacity = max(rate, 1)
+	l.tokens = min(l.tokens, l.capacity)
+}
@@ -0,0 +1,241 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	DefaultPollInterval     = 4 * time.Second
+	DefaultFailureThreshold = 5
+	DefaultBreakerCooldown  = time.Minute
+
+	// HealthService is the name the relay reports under on the gRPC health service.
+	HealthService = "grpc-products-service.outbox-relay"
+)
+
+// BreakerState is the state of the supervisor's circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// Leader reports whether this instance is allowed to relay.
+type Leader interface {
+	// Lead returns a context derived from ctx that is cancelled once
+	// leadership is lost, and false when this instance is not the leader.
+	Lead(ctx context.Context) (context.Context, context.CancelFunc, bool)
+}
+
+// HealthReporter is the subset of the gRPC health server the supervisor updates.
+type HealthReporter interface {
+	SetServingStatus(service string, servingStatus healthpb.HealthCheckResponse_ServingStatus)
+}
+
+// SupervisorConfig holds the configuration for the relay supervisor.
+type SupervisorConfig struct {
+	PollInterval     time.Duration
+	FailureThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// Supervisor runs the relay on an interval, backs off after failures and
+// trips a circuit breaker when they keep happening, instead of taking the
+// whole process down.
+type Supervisor struct {
+	relay  *Relay
+	leader Leader
+	health HealthReporter
+
+	leading bool
+
+	mu        sync.RWMutex
+	interval  time.Duration
+	threshold int
+	cooldown  time.Duration
+	state     BreakerState
+	failures  int
+	lastErr   error
+}
+
+// NewSupervisor initializes and returns a Supervisor for the relay. A nil
+// leader means this instance always relays; a nil health reporter disables
+// health reporting.
+func NewSupervisor(cfg *SupervisorConfig, relay *Relay, leader Leader, health HealthReporter) *Supervisor {
+	s := &Supervisor{
+		relay:  relay,
+		leader: leader,
+		health: health,
+	}
+	s.Update(cfg)
+
+	return s
+}
+
+// Update changes the poll interval and breaker settings, they apply from the
+// next relay run.
+func (s *Supervisor) Update(cfg *SupervisorConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.interval = cfg.PollInterval
+	s.threshold = cfg.FailureThreshold
+	s.cooldown = cfg.BreakerCooldown
+
+	if s.interval <= 0 {
+		s.interval = DefaultPollInterval
+	}
+	if s.threshold <= 0 {
+		s.threshold = DefaultFailureThreshold
+	}
+	if s.cooldown <= 0 {
+		s.cooldown = DefaultBreakerCooldown
+	}
+}
+
+func (s *Supervisor) pollInterval() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.interval
+}
+
+// State returns the breaker state, the number of consecutive failures and the last error.
+func (s *Supervisor) State() (BreakerState, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.state, s.failures, s.lastErr
+}
+
+// Run relays the outbox until ctx is done.
+func (s *Supervisor) Run(ctx context.Context) {
+	s.setHealth(healthpb.HealthCheckResponse_SERVING)
+
+	timer := time.NewTimer(s.pollInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			timer.Reset(s.step(ctx))
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// step runs the relay once and returns how long to wait before the next run.
+// The run is given a context cancelled as soon as leadership is lost, so a
+// replica that lost its lease stops publishing mid-run.
+func (s *Supervisor) step(ctx context.Context) time.Duration {
+	runCtx := ctx
+	if s.leader != nil {
+		leadCtx, cancel, ok := s.leader.Lead(ctx)
+		defer cancel()
+		if !ok {
+			s.stopLeading()
+			return s.pollInterval()
+		}
+		runCtx = leadCtx
+	}
+	s.leading = true
+
+	s.mu.Lock()
+	if s.state == BreakerOpen {
+		s.state = BreakerHalfOpen
+		slog.Info("relay circuit breaker half-open, trying again")
+	}
+	s.mu.Unlock()
+
+	err := s.relay.ProcessMessages(runCtx)
+	if ctx.Err() != nil {
+		return s.pollInterval()
+	}
+	if runCtx.Err() != nil {
+		s.stopLeading()
+		return s.pollInterval()
+	}
+	if err != nil {
+		return s.recordFailure(err)
+	}
+
+	s.recordSuccess()
+	return s.pollInterval()
+}
+
+// stopLeading releases the producers once after leadership was lost.
+func (s *Supervisor) stopLeading() {
+	if !s.leading {
+		return
+	}
+	s.leading = false
+	s.relay.Release()
+	slog.Info("lost relay leadership, producers released")
+}
+
+func (s *Supervisor) recordSuccess() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		slog.Info("relay recovered", "failures", s.failures)
+	}
+	s.failures = 0
+	s.lastErr = nil
+
+	if s.state != BreakerClosed {
+		s.state = BreakerClosed
+		s.setHealth(healthpb.HealthCheckResponse_SERVING)
+	}
+}
+
+func (s *Supervisor) recordFailure(err error) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures++
+	s.lastErr = err
+
+	if s.state == BreakerHalfOpen || s.failures >= s.threshold {
+		if s.state != BreakerOpen {
+			slog.Error("relay circuit breaker open", "failures", s.failures, "cooldown", s.cooldown, "error", err)
+			s.setHealth(healthpb.HealthCheckResponse_NOT_SERVING)
+		}
+		s.state = BreakerOpen
+		return s.cooldown
+	}
+
+	delay := s.interval
+	for i := 1; i < s.failures && delay < s.cooldown; i++ {
+		delay *= 2
+	}
+	delay = min(delay, s.cooldown)
+
+	slog.Warn("failed to process messages, backing off", "failures", s.failures, "retryIn", delay, "error", err)
+	return delay
+}
+
+func (s *Supervisor) setHealth(servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	if s.health != nil {
+		s.health.SetServingStatus(HealthService, servingStatus)
+	}
+}
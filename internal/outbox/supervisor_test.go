@@ -0,0 +1,204 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// fakeLeader leads while leading is set; a lost term hands out contexts that
+// are already cancelled, as after losing the lease mid-run.
+type fakeLeader struct {
+	leading bool
+	lost    bool
+}
+
+func (l *fakeLeader) Lead(ctx context.Context) (context.Context, context.CancelFunc, bool) {
+	if !l.leading {
+		return ctx, func() {}, false
+	}
+	leadCtx, cancel := context.WithCancelCause(ctx)
+	if l.lost {
+		cancel(errors.New("leadership lost"))
+	}
+	return leadCtx, func() { cancel(nil) }, true
+}
+
+// fakeHealth records the last status reported.
+type fakeHealth struct {
+	status healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (h *fakeHealth) SetServingStatus(_ string, status healthpb.HealthCheckResponse_ServingStatus) {
+	h.status = status
+}
+
+// failingStore fails to load the checkpoint while err is set, failing the
+// whole relay run.
+type failingStore struct {
+	*store.MemoryStore
+	err error
+}
+
+func (s *failingStore) GetOutboxCheckpoint(ctx context.Context) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	return s.MemoryStore.GetOutboxCheckpoint(ctx)
+}
+
+func TestSupervisorBreaker(t *testing.T) {
+	const (
+		interval = time.Second
+		cooldown = time.Minute
+	)
+	storeErr := errors.New("cassandra unavailable")
+
+	tests := []struct {
+		name string
+		runs []error // outcome of each relay run
+
+		wantState    BreakerState
+		wantFailures int
+		wantDelay    time.Duration
+		wantHealth   healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{
+			name:       "success",
+			runs:       []error{nil},
+			wantState:  BreakerClosed,
+			wantDelay:  interval,
+			wantHealth: healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:         "failures back off",
+			runs:         []error{storeErr, storeErr},
+			wantState:    BreakerClosed,
+			wantFailures: 2,
+			wantDelay:    2 * interval,
+			wantHealth:   healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:         "threshold opens the breaker",
+			runs:         []error{storeErr, storeErr, storeErr},
+			wantState:    BreakerOpen,
+			wantFailures: 3,
+			wantDelay:    cooldown,
+			wantHealth:   healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:         "failed half-open run opens it again",
+			runs:         []error{storeErr, storeErr, storeErr, storeErr},
+			wantState:    BreakerOpen,
+			wantFailures: 4,
+			wantDelay:    cooldown,
+			wantHealth:   healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:       "successful half-open run closes it",
+			runs:       []error{storeErr, storeErr, storeErr, nil},
+			wantState:  BreakerClosed,
+			wantDelay:  interval,
+			wantHealth: healthpb.HealthCheckResponse_SERVING,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxStore := &failingStore{MemoryStore: store.NewMemoryStore()}
+			relay, err := NewRelay(&RelayConfig{}, outboxStore, &fakePublisher{})
+			if err != nil {
+				t.Fatalf("failed to create relay: %v", err)
+			}
+			health := &fakeHealth{}
+			supervisor := NewSupervisor(&SupervisorConfig{
+				PollInterval:     interval,
+				FailureThreshold: 3,
+				BreakerCooldown:  cooldown,
+			}, relay, nil, health)
+			supervisor.setHealth(healthpb.HealthCheckResponse_SERVING)
+
+			var delay time.Duration
+			for _, run := range tt.runs {
+				outboxStore.err = run
+				delay = supervisor.step(context.Background())
+			}
+
+			state, failures, lastErr := supervisor.State()
+			if state != tt.wantState || failures != tt.wantFailures {
+				t.Errorf("state = %v with %d failures, want %v with %d", state, failures, tt.wantState, tt.wantFailures)
+			}
+			if (lastErr != nil) != (tt.wantFailures > 0) {
+				t.Errorf("last error = %v", lastErr)
+			}
+			if delay != tt.wantDelay {
+				t.Errorf("delay = %v, want %v", delay, tt.wantDelay)
+			}
+			if health.status != tt.wantHealth {
+				t.Errorf("health = %v, want %v", health.status, tt.wantHealth)
+			}
+		})
+	}
+}
+
+func TestSupervisorLeadership(t *testing.T) {
+	tests := []struct {
+		name   string
+		leader *fakeLeader
+		// after replaces leader once the first step ran
+		after *fakeLeader
+
+		wantPublished int
+		wantReleased  int
+	}{
+		{
+			name:          "leader relays",
+			leader:        &fakeLeader{leading: true},
+			wantPublished: 1,
+		},
+		{
+			name:   "follower does not relay",
+			leader: &fakeLeader{},
+		},
+		{
+			name:         "lost lease stops the run",
+			leader:       &fakeLeader{leading: true, lost: true},
+			wantReleased: 1,
+		},
+		{
+			name:          "producers are released once leadership ends",
+			leader:        &fakeLeader{leading: true},
+			after:         &fakeLeader{},
+			wantPublished: 1,
+			wantReleased:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			relay, _, pub := newTestRelay(t, 3, productRow(t, testNow, 1, "lamp", 1))
+			supervisor := NewSupervisor(&SupervisorConfig{}, relay, tt.leader, nil)
+
+			supervisor.step(context.Background())
+			if tt.after != nil {
+				supervisor.leader = tt.after
+				supervisor.step(context.Background())
+				supervisor.step(context.Background())
+			}
+
+			if got := len(pub.messages()); got != tt.wantPublished {
+				t.Errorf("published %d messages, want %d", got, tt.wantPublished)
+			}
+			if pub.released != tt.wantReleased {
+				t.Errorf("released %d times, want %d", pub.released, tt.wantReleased)
+			}
+			if state, failures, _ := supervisor.State(); state != BreakerClosed || failures != 0 {
+				t.Errorf("state = %v with %d failures, want closed", state, failures)
+			}
+		})
+	}
+}
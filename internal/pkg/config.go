@@ -8,25 +8,156 @@ import (
 )
 
 type Config struct {
-	Server   Server `yaml:"server"`
-	Database DB     `yaml:"database"`
-	Queue    Pulsar `yaml:"pulsar"`
+	Server    Server    `yaml:"server"`
+	Database  DB        `yaml:"database"`
+	Queue     Pulsar    `yaml:"pulsar"`
+	Publisher Publisher `yaml:"publisher"`
+	Outbox    Outbox    `yaml:"outbox"`
+	Secrets   Secrets   `yaml:"secrets"`
+	Tracing   Tracing   `yaml:"tracing"`
 }
 
 type Server struct {
-	Port int `yaml:"port"`
+	Port         int    `yaml:"port"`
+	DisableRelay bool   `yaml:"disable_relay"` // when the outbox is relayed by the standalone relay command
+	LogLevel     string `yaml:"log_level"`     // debug, info, warn or error
+	LogFormat    string `yaml:"log_format"`    // text or json
+	MetricsPort  int    `yaml:"metrics_port"`  // prometheus /metrics endpoint of the server and projector
+	AdminAddress string `yaml:"admin_address"` // host:port of the dead-letter and replay admin RPCs, keep it off the public network
 }
 
 type DB struct {
+	Kind     string `yaml:"kind"` // astra (default) or cassandra, for self-hosted Cassandra and ScyllaDB
 	Username string `yaml:"username"`
-	Path     string `yaml:"path"`
-	Timeout  int    `yaml:"timeout"`
+	Path     string `yaml:"path"`    // astra secure-connect bundle
+	Timeout  int    `yaml:"timeout"` // seconds a query may take
+
+	Hosts   []string `yaml:"hosts"`    // cassandra contact points, host or host:port
+	Port    int      `yaml:"port"`     // cassandra port for hosts without one
+	LocalDC string   `yaml:"local_dc"` // cassandra datacenter queried first
+	TLS     DBTLS    `yaml:"tls"`
+
+	ConnectTimeout    int                `yaml:"connect_timeout"`    // seconds to establish a connection
+	NumConns          int                `yaml:"num_conns"`          // connections per host
+	ReadConsistency   string             `yaml:"read_consistency"`   // e.g. LOCAL_QUORUM or LOCAL_ONE
+	WriteConsistency  string             `yaml:"write_consistency"`  // also the commit consistency of LWTs
+	SerialConsistency string             `yaml:"serial_consistency"` // SERIAL or LOCAL_SERIAL, for LWTs
+	PageSize          int                `yaml:"page_size"`
+	Retry             DBRetry            `yaml:"retry"`
+	Speculative       DBSpeculative      `yaml:"speculative"`
+	Queries           map[string]DBQuery `yaml:"queries"` // overrides per query type: read, scan, write or lwt
+}
+
+// DBRetry retries failed queries with exponential backoff.
+type DBRetry struct {
+	Attempts   int `yaml:"attempts"`    // retries after the first attempt, 0 disables them
+	MinBackoff int `yaml:"min_backoff"` // milliseconds
+	MaxBackoff int `yaml:"max_backoff"` // milliseconds
+}
+
+// DBSpeculative sends idempotent reads to more hosts when the first is slow.
+type DBSpeculative struct {
+	Attempts int `yaml:"attempts"` // extra executions, 0 disables speculative execution
+	Delay    int `yaml:"delay"`    // milliseconds before each extra execution
+}
+
+// DBQuery overrides the driver settings for one query type, unset fields
+// keep the database wide settings.
+type DBQuery struct {
+	Consistency       string         `yaml:"consistency"`
+	SerialConsistency string         `yaml:"serial_consistency"`
+	PageSize          int            `yaml:"page_size"`
+	Retry             *DBRetry       `yaml:"retry"`
+	Speculative       *DBSpeculative `yaml:"speculative"`
+}
+
+// DBTLS configures TLS to a self-hosted cluster.
+type DBTLS struct {
+	Enabled            bool   `yaml:"enabled"`
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"` // client certificate, when the cluster requires one
+	KeyFile            string `yaml:"key_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
 }
 
 type Pulsar struct {
-	Uri              string `yaml:"uri"`
-	TopicName        string `yaml:"topic_name"`
-	SubscriptionName string `yaml:"subscriptionName"` //incase of consumer
+	Uri              string            `yaml:"uri"`
+	AdminURL         string            `yaml:"admin_url"`        // web service url, required by the startup schema check
+	TopicName        string            `yaml:"topic_name"`       // fallback for event types without a route
+	Routes           map[string]string `yaml:"routes"`           // outbox event type, e.g. CREATE_PRODUCT, to topic
+	SubscriptionName string            `yaml:"subscriptionName"` //incase of consumer
+	CloudEvents      CloudEvents       `yaml:"cloudevents"`
+
+	ProducerName       string `yaml:"producer_name"`       // enables broker deduplication, requires it on the namespace
+	Transactions       bool   `yaml:"transactions"`        // publish outbox batches in transactions
+	TransactionTimeout int    `yaml:"transaction_timeout"` // seconds before an open transaction is aborted
+}
+
+type CloudEvents struct {
+	Source string `yaml:"source"`
+	Mode   string `yaml:"mode"`   // structured or binary
+	Format string `yaml:"format"` // protobuf (binary mode only) or json
+}
+
+// Publisher selects where the outbox relay publishes events.
+type Publisher struct {
+	Kind  string `yaml:"kind"` // pulsar (default), kafka, nats, file or stdout
+	Kafka Kafka  `yaml:"kafka"`
+	NATS  NATS   `yaml:"nats"`
+	File  File   `yaml:"file"`
+}
+
+type Kafka struct {
+	Brokers []string          `yaml:"brokers"`
+	Topic   string            `yaml:"topic"`  // fallback for event types without a route
+	Routes  map[string]string `yaml:"routes"` // outbox event type to topic
+}
+
+type NATS struct {
+	URL       string            `yaml:"url"`
+	Subject   string            `yaml:"subject"`   // fallback for event types without a route
+	Routes    map[string]string `yaml:"routes"`    // outbox event type to subject
+	JetStream bool              `yaml:"jetstream"` // publish to a stream and wait for its acknowledgement
+}
+
+type File struct {
+	Path string `yaml:"path"` // events are appended as JSON lines
+}
+
+type Outbox struct {
+	LookbackDays int `yaml:"lookback_days"` // how far back to scan when no checkpoint exists
+	Shards       int `yaml:"shards"`        // buckets per hourly window, changes apply from the next window
+	LeaseTTL     int `yaml:"lease_ttl"`     // seconds a relay leader keeps the lease without a heartbeat
+	MaxAttempts  int `yaml:"max_attempts"`  // publish attempts before an event is dead-lettered
+	RetryBackoff int `yaml:"retry_backoff"` // seconds before the first retry, doubled on every attempt
+	MaxBackoff   int `yaml:"max_backoff"`   // upper bound in seconds for the retry backoff
+
+	PollInterval     int `yaml:"poll_interval"`     // seconds between relay runs
+	FailureThreshold int `yaml:"failure_threshold"` // consecutive relay failures before the breaker opens
+	BreakerCooldown  int `yaml:"breaker_cooldown"`  // seconds the breaker stays open before a trial run
+	ReplayRate       int `yaml:"replay_rate"`       // replayed events published per second
+
+	HealthPort int `yaml:"health_port"` // http health endpoint of the standalone relay command
+}
+
+// Secrets selects where the Astra and Pulsar tokens are read from.
+type Secrets struct {
+	Providers        []string `yaml:"providers"`         // tried in order: env, file, ssm or encrypted
+	SSMPrefix        string   `yaml:"ssm_prefix"`        // parameters are read as <prefix>/<NAME>
+	Dir              string   `yaml:"dir"`               // mounted secret files, e.g. /run/secrets
+	EncryptedFile    string   `yaml:"encrypted_file"`    // sealed with the key in PRODUCTS_SECRETS_KEY
+	CacheTTL         int      `yaml:"cache_ttl"`         // seconds a secret is served before it is fetched again, 0 until rotated
+	RotationInterval int      `yaml:"rotation_interval"` // seconds between background refreshes
+}
+
+// Tracing selects where OpenTelemetry spans are exported.
+type Tracing struct {
+	Exporter    string            `yaml:"exporter"`     // otlp, file or none
+	Endpoint    string            `yaml:"endpoint"`     // otlp collector host:port, gRPC
+	Insecure    bool              `yaml:"insecure"`     // connect to the collector without TLS
+	Headers     map[string]string `yaml:"headers"`      // sent to the collector, e.g. an API key
+	File        string            `yaml:"file"`         // spans are appended as JSON lines
+	SampleRatio float64           `yaml:"sample_ratio"` // share of new traces recorded, 0 to 1
 }
 
 func (c *Config) LoadConfig(file io.Reader) error {
@@ -0,0 +1,216 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix prefixes the environment variables overriding configuration
+// fields, e.g. PRODUCTS_SERVER_PORT or PRODUCTS_OUTBOX_POLL_INTERVAL.
+const EnvPrefix = "PRODUCTS"
+
+// Source overlays configuration onto cfg, leaving fields it does not set untouched.
+type Source interface {
+	Name() string
+	Load(ctx context.Context, cfg *Config) error
+}
+
+// Default returns the configuration the service runs with when no source
+// sets a field.
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:         50051,
+			LogLevel:     "info",
+			LogFormat:    "text",
+			MetricsPort:  9090,
+			AdminAddress: "127.0.0.1:50052",
+		},
+		Database: DB{
+			Kind:     "astra",
+			Username: "token",
+			Path:     "./secure-connect.zip",
+			Timeout:  30,
+			Port:     9042,
+
+			ConnectTimeout:    10,
+			NumConns:          2,
+			ReadConsistency:   "LOCAL_QUORUM",
+			WriteConsistency:  "LOCAL_QUORUM",
+			SerialConsistency: "LOCAL_SERIAL",
+			PageSize:          5000,
+			Retry: DBRetry{
+				Attempts:   3,
+				MinBackoff: 100,
+				MaxBackoff: 2000,
+			},
+		},
+		Queue: Pulsar{
+			TransactionTimeout: 60,
+		},
+		Publisher: Publisher{
+			Kind: "pulsar",
+		},
+		Outbox: Outbox{
+			LookbackDays:     7,
+			Shards:           8,
+			LeaseTTL:         15,
+			MaxAttempts:      10,
+			RetryBackoff:     4,
+			MaxBackoff:       600,
+			PollInterval:     4,
+			FailureThreshold: 5,
+			BreakerCooldown:  60,
+			ReplayRate:       50,
+			HealthPort:       8081,
+		},
+		Secrets: Secrets{
+			Providers:        []string{"env"},
+			SSMPrefix:        "/myapp",
+			RotationInterval: 300,
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			Endpoint:    "localhost:4317",
+			File:        "./traces.jsonl",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load applies the sources in order on top of the defaults, later sources
+// overriding earlier ones.
+func Load(ctx context.Context, sources ...Source) (*Config, error) {
+	cfg := Default()
+	for _, source := range sources {
+		if err := source.Load(ctx, cfg); err != nil {
+			return nil, fmt.Errorf("failed to load config from %s: %w", source.Name(), err)
+		}
+		slog.Info("config loaded", "source", source.Name())
+	}
+	return cfg, nil
+}
+
+// FileSource reads a YAML config file.
+type FileSource struct {
+	Path string
+}
+
+func (s FileSource) Name() string {
+	return "file " + s.Path
+}
+
+func (s FileSource) Load(_ context.Context, cfg *Config) error {
+	file, err := os.Open(s.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return cfg.LoadConfig(file)
+}
+
+// EnvSource overrides fields from environment variables named after their
+// YAML keys: the prefix, then every key of the path in upper case, joined by
+// underscores. Lists are comma separated and maps are comma separated
+// key=value pairs.
+type EnvSource struct {
+	Prefix string
+}
+
+func (s EnvSource) Name() string {
+	return "environment"
+}
+
+func (s EnvSource) Load(_ context.Context, cfg *Config) error {
+	prefix := s.Prefix
+	if prefix == "" {
+		prefix = EnvPrefix
+	}
+	return overrideFromEnv(reflect.ValueOf(cfg).Elem(), prefix)
+}
+
+func overrideFromEnv(v reflect.Value, name string) error {
+	if v.Kind() == reflect.Struct {
+		for i := range v.NumField() {
+			tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("yaml"), ",")
+			if tag == "" || tag == "-" {
+				continue
+			}
+			if err := overrideFromEnv(v.Field(i), name+"_"+strings.ToUpper(tag)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil
+	}
+	if err := setFromString(v, value); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
+func setFromString(v reflect.Value, value string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		v.Set(reflect.ValueOf(splitList(value)))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", v.Type())
+		}
+		entries := make(map[string]string)
+		for _, entry := range splitList(value) {
+			key, val, ok := strings.Cut(entry, "=")
+			if !ok {
+				return fmt.Errorf("entry %q is not a key=value pair", entry)
+			}
+			entries[strings.TrimSpace(key)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(entries))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
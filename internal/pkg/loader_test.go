@@ -0,0 +1,133 @@
+package pkg
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// yamlSource stands in for the SSM parameter, which carries a YAML
+// document like the config file.
+type yamlSource string
+
+func (s yamlSource) Name() string {
+	return "ssm"
+}
+
+func (s yamlSource) Load(_ context.Context, cfg *Config) error {
+	return cfg.LoadConfig(strings.NewReader(string(s)))
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	file := writeConfigFile(t, `
+server:
+  port: 6000
+  log_level: debug
+outbox:
+  shards: 4
+  poll_interval: 2
+pulsar:
+  routes:
+    CREATE_CATEGORY: categories
+`)
+	ssm := yamlSource(`
+server:
+  port: 7000
+outbox:
+  shards: 16
+`)
+	t.Setenv("PRODUCTS_SERVER_PORT", "8000")
+	t.Setenv("PRODUCTS_SECRETS_PROVIDERS", "env, ssm")
+	t.Setenv("PRODUCTS_PULSAR_ROUTES", "CREATE_PRODUCT=products, DELETE_PRODUCT=deletions")
+
+	cfg, err := Load(context.Background(), FileSource{Path: file}, ssm, EnvSource{Prefix: EnvPrefix})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{name: "default", got: cfg.Server.MetricsPort, want: 9090},
+		{name: "file over the defaults", got: cfg.Server.LogLevel, want: "debug"},
+		{name: "file without a later layer", got: cfg.Outbox.PollInterval, want: 2},
+		{name: "ssm over the file", got: cfg.Outbox.Shards, want: 16},
+		{name: "env over ssm", got: cfg.Server.Port, want: 8000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+
+	if want := []string{"env", "ssm"}; !slices.Equal(cfg.Secrets.Providers, want) {
+		t.Errorf("secrets.providers = %v, want %v", cfg.Secrets.Providers, want)
+	}
+	// an env map replaces the routes of the earlier layers
+	if want := map[string]string{"CREATE_PRODUCT": "products", "DELETE_PRODUCT": "deletions"}; !maps.Equal(cfg.Queue.Routes, want) {
+		t.Errorf("pulsar.routes = %v, want %v", cfg.Queue.Routes, want)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  func(t *testing.T) Source
+		env     map[string]string
+		wantErr string
+	}{
+		{
+			name:    "missing file",
+			source:  func(t *testing.T) Source { return FileSource{Path: filepath.Join(t.TempDir(), "missing.yaml")} },
+			wantErr: "failed to load config from file",
+		},
+		{
+			name:    "malformed ssm parameter",
+			source:  func(*testing.T) Source { return yamlSource("server: [") },
+			wantErr: "failed to load config from ssm",
+		},
+		{
+			name:    "env value of the wrong type",
+			source:  func(*testing.T) Source { return EnvSource{} },
+			env:     map[string]string{"PRODUCTS_SERVER_PORT": "http"},
+			wantErr: "invalid PRODUCTS_SERVER_PORT",
+		},
+		{
+			name:    "env map entry without a value",
+			source:  func(*testing.T) Source { return EnvSource{} },
+			env:     map[string]string{"PRODUCTS_PULSAR_ROUTES": "CREATE_PRODUCT"},
+			wantErr: "invalid PRODUCTS_PULSAR_ROUTES",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			_, err := Load(context.Background(), tt.source(t))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// LiveFields are the settings a reload applies to the running process, any
+// other change is logged and waits for a restart.
+var LiveFields = map[string]bool{
+	"server.log_level":         true,
+	"outbox.max_attempts":      true,
+	"outbox.retry_backoff":     true,
+	"outbox.max_backoff":       true,
+	"outbox.poll_interval":     true,
+	"outbox.failure_threshold": true,
+	"outbox.breaker_cooldown":  true,
+	"outbox.replay_rate":       true,
+}
+
+// Change is a field whose value differs between two configs, named by its
+// dotted YAML path.
+type Change struct {
+	Path string
+	Old  any
+	New  any
+
+	index []int
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Diff returns the fields that differ between old and new.
+func Diff(old, new *Config) []Change {
+	var changes []Change
+	diffValues(reflect.ValueOf(old).Elem(), reflect.ValueOf(new).Elem(), "", nil, &changes)
+	return changes
+}
+
+func diffValues(old, new reflect.Value, path string, index []int, changes *[]Change) {
+	if old.Kind() != reflect.Struct {
+		if !reflect.DeepEqual(old.Interface(), new.Interface()) {
+			*changes = append(*changes, Change{Path: path, Old: old.Interface(), New: new.Interface(), index: index})
+		}
+		return
+	}
+
+	for i := range old.NumField() {
+		tag, _, _ := strings.Cut(old.Type().Field(i).Tag.Get("yaml"), ",")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		fieldPath := tag
+		if path != "" {
+			fieldPath = path + "." + tag
+		}
+		diffValues(old.Field(i), new.Field(i), fieldPath, append(index[:len(index):len(index)], i), changes)
+	}
+}
+
+// Reloader reloads the config on demand and hands the live subset of what
+// changed to the running components.
+type Reloader struct {
+	load     func(ctx context.Context) (*Config, error)
+	validate func(*Config) error
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(*Config)
+}
+
+// NewReloader initializes and returns a Reloader starting from the config
+// the process was started with, reloading it with load and checking it with
+// the validate the process started with, such as (*Config).ValidateProjector.
+func NewReloader(current *Config, load func(ctx context.Context) (*Config, error), validate func(*Config) error) *Reloader {
+	return &Reloader{
+		load:     load,
+		validate: validate,
+		current:  current,
+	}
+}
+
+// OnReload registers apply to be called with the config after every reload
+// that changed a live setting.
+func (r *Reloader) OnReload(apply func(*Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, apply)
+}
+
+// Current returns the config in effect.
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// Reload loads the config again and applies its live settings. A config that
+// fails to load or to validate is rejected and the current one stays in
+// effect.
+func (r *Reloader) Reload(ctx context.Context) error {
+	next, err := r.load(ctx)
+	if err != nil {
+		return err
+	}
+	if err := r.validate(next); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	applied := *r.current
+	live := false
+	for _, change := range Diff(r.current, next) {
+		if !LiveFields[change.Path] {
+			slog.Warn("config change requires a restart", "field", change.Path, "old", change.Old, "new", change.New)
+			continue
+		}
+		reflect.ValueOf(&applied).Elem().FieldByIndex(change.index).Set(reflect.ValueOf(change.New))
+		slog.Info("config changed", "field", change.Path, "old", change.Old, "new", change.New)
+		live = true
+	}
+	if !live {
+		slog.Info("config reloaded, no live setting changed")
+		return nil
+	}
+
+	r.current = &applied
+	for _, apply := range r.subscribers {
+		apply(r.current)
+	}
+	return nil
+}
+
+// ParseLogLevel parses debug, info, warn or error, an empty level is info.
+func ParseLogLevel(level string) (slog.Level, error) {
+	var l slog.Level
+	if level == "" {
+		return slog.LevelInfo, nil
+	}
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return 0, fmt.Errorf("invalid server.log_level %q", level)
+	}
+	return l, nil
+}
@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"context"
+	"testing"
+)
+
+// projectorConfig returns a config the projector runs with but the server
+// rejects, as it has no Pulsar admin url for the relay.
+func projectorConfig() *Config {
+	cfg := validConfig()
+	cfg.Queue.SubscriptionName = "projection"
+	cfg.Queue.AdminURL = ""
+	return cfg
+}
+
+func TestReloaderReload(t *testing.T) {
+	tests := []struct {
+		name     string
+		validate func(cfg *Config) error
+		mutate   func(cfg *Config)
+
+		wantErr      bool
+		wantApplied  bool
+		wantLogLevel string
+		wantShards   int
+	}{
+		{
+			name:         "live setting is applied",
+			validate:     (*Config).ValidateProjector,
+			mutate:       func(cfg *Config) { cfg.Server.LogLevel = "debug" },
+			wantApplied:  true,
+			wantLogLevel: "debug",
+			wantShards:   8,
+		},
+		{
+			name:         "restart setting waits",
+			validate:     (*Config).ValidateProjector,
+			mutate:       func(cfg *Config) { cfg.Outbox.Shards = 16 },
+			wantLogLevel: "info",
+			wantShards:   8,
+		},
+		{
+			name:         "invalid config is rejected",
+			validate:     (*Config).ValidateProjector,
+			mutate:       func(cfg *Config) { cfg.Queue.SubscriptionName = ""; cfg.Server.LogLevel = "debug" },
+			wantErr:      true,
+			wantLogLevel: "info",
+			wantShards:   8,
+		},
+		{
+			name:         "reload validates like the process started",
+			validate:     (*Config).Validate,
+			mutate:       func(cfg *Config) { cfg.Server.LogLevel = "debug" },
+			wantErr:      true,
+			wantLogLevel: "info",
+			wantShards:   8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := projectorConfig()
+			tt.mutate(next)
+			reloader := NewReloader(projectorConfig(), func(context.Context) (*Config, error) {
+				return next, nil
+			}, tt.validate)
+
+			var applied *Config
+			reloader.OnReload(func(cfg *Config) { applied = cfg })
+
+			err := reloader.Reload(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (applied != nil) != tt.wantApplied {
+				t.Errorf("subscribers called = %v, want %v", applied != nil, tt.wantApplied)
+			}
+
+			current := reloader.Current()
+			if current.Server.LogLevel != tt.wantLogLevel || current.Outbox.Shards != tt.wantShards {
+				t.Errorf("current log level %q and %d shards, want %q and %d",
+					current.Server.LogLevel, current.Outbox.Shards, tt.wantLogLevel, tt.wantShards)
+			}
+		})
+	}
+}
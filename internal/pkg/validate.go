@@ -0,0 +1,303 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// component is the command a config is validated for.
+type component int
+
+const (
+	componentServer component = iota
+	componentRelay
+	componentProjector
+)
+
+// Validate checks the config as a whole and reports every problem at once,
+// joined into a single error with one problem per line.
+func (c *Config) Validate() error {
+	return c.validate(componentServer)
+}
+
+// ValidateRelay checks the config of the relay command, which relays the
+// outbox whatever server.disable_relay says.
+func (c *Config) ValidateRelay() error {
+	return c.validate(componentRelay)
+}
+
+// ValidateProjector checks the config of the projector command, which
+// always consumes its events from a Pulsar subscription.
+func (c *Config) ValidateProjector() error {
+	return c.validate(componentProjector)
+}
+
+func (c *Config) validate(component component) error {
+	var v validator
+	projector := component == componentProjector
+	relays := component == componentRelay || (component == componentServer && !c.Server.DisableRelay)
+
+	v.check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535, got %d", c.Server.Port)
+	if _, err := ParseLogLevel(c.Server.LogLevel); err != nil {
+		v.add(err)
+	}
+	v.check(slices.Contains([]string{"", "text", "json"}, c.Server.LogFormat), "server.log_format must be text or json, got %q", c.Server.LogFormat)
+	v.check(c.Server.MetricsPort > 0 && c.Server.MetricsPort <= 65535, "server.metrics_port must be between 1 and 65535, got %d", c.Server.MetricsPort)
+	v.check(c.Server.MetricsPort != c.Server.Port, "server.metrics_port must differ from server.port")
+	c.validateAdminAddress(&v)
+
+	c.validateDatabase(&v)
+
+	switch c.Publisher.Kind {
+	case "", "pulsar":
+		c.validatePulsar(&v)
+		// the relay checks its schema against the broker before publishing
+		if relays {
+			v.required("pulsar.admin_url", c.Queue.AdminURL)
+		}
+	case "kafka":
+		v.check(len(c.Publisher.Kafka.Brokers) > 0, "publisher.kafka.brokers is required")
+		// routes only cover some event types, the rest go to the topic
+		v.required("publisher.kafka.topic", c.Publisher.Kafka.Topic)
+	case "nats":
+		// the publisher connects to the local server when no url is set
+		if c.Publisher.NATS.URL != "" {
+			v.uri("publisher.nats.url", c.Publisher.NATS.URL, "nats", "tls")
+		}
+		v.required("publisher.nats.subject", c.Publisher.NATS.Subject)
+	case "file":
+		v.required("publisher.file.path", c.Publisher.File.Path)
+	case "stdout":
+	default:
+		v.add(fmt.Errorf("publisher.kind must be one of pulsar, kafka, nats, file or stdout, got %q", c.Publisher.Kind))
+	}
+	// the projector consumes from Pulsar whatever the relay publishes to, a
+	// subscription means the config is shared with it
+	if projector {
+		v.required("pulsar.subscriptionName", c.Queue.SubscriptionName)
+	}
+	if (projector || c.Queue.SubscriptionName != "") && c.Publisher.Kind != "" && c.Publisher.Kind != "pulsar" {
+		c.validatePulsar(&v)
+	}
+
+	v.check(c.Outbox.LookbackDays > 0, "outbox.lookback_days must be positive, got %d", c.Outbox.LookbackDays)
+	v.check(c.Outbox.Shards > 0, "outbox.shards must be positive, got %d", c.Outbox.Shards)
+	v.check(c.Outbox.LeaseTTL > 0, "outbox.lease_ttl must be positive, got %d", c.Outbox.LeaseTTL)
+	v.check(c.Outbox.MaxAttempts > 0, "outbox.max_attempts must be positive, got %d", c.Outbox.MaxAttempts)
+	v.check(c.Outbox.RetryBackoff > 0, "outbox.retry_backoff must be positive, got %d", c.Outbox.RetryBackoff)
+	v.check(c.Outbox.MaxBackoff >= c.Outbox.RetryBackoff, "outbox.max_backoff must not be below outbox.retry_backoff, got %d", c.Outbox.MaxBackoff)
+	v.check(c.Outbox.PollInterval > 0, "outbox.poll_interval must be positive, got %d", c.Outbox.PollInterval)
+	v.check(c.Outbox.FailureThreshold > 0, "outbox.failure_threshold must be positive, got %d", c.Outbox.FailureThreshold)
+	v.check(c.Outbox.BreakerCooldown > 0, "outbox.breaker_cooldown must be positive, got %d", c.Outbox.BreakerCooldown)
+	v.check(c.Outbox.ReplayRate > 0, "outbox.replay_rate must be positive, got %d", c.Outbox.ReplayRate)
+	v.check(c.Outbox.HealthPort > 0 && c.Outbox.HealthPort <= 65535, "outbox.health_port must be between 1 and 65535, got %d", c.Outbox.HealthPort)
+
+	c.validateSecrets(&v)
+
+	switch c.Tracing.Exporter {
+	case "", "none":
+	case "otlp":
+		v.required("tracing.endpoint", c.Tracing.Endpoint)
+	case "file":
+		v.required("tracing.file", c.Tracing.File)
+	default:
+		v.add(fmt.Errorf("tracing.exporter must be otlp, file or none, got %q", c.Tracing.Exporter))
+	}
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1, got %g", c.Tracing.SampleRatio)
+
+	return errors.Join(v.errs...)
+}
+
+func (c *Config) validateDatabase(v *validator) {
+	db := c.Database
+	switch db.Kind {
+	case "", "astra":
+		v.required("database.username", db.Username)
+		v.file("database.path", db.Path)
+	case "cassandra":
+		v.check(len(db.Hosts) > 0, "database.hosts is required")
+		v.check(db.Port > 0 && db.Port <= 65535, "database.port must be between 1 and 65535, got %d", db.Port)
+		if db.TLS.Enabled {
+			if db.TLS.CAFile != "" {
+				v.file("database.tls.ca_file", db.TLS.CAFile)
+			}
+			if db.TLS.CertFile != "" || db.TLS.KeyFile != "" {
+				v.file("database.tls.cert_file", db.TLS.CertFile)
+				v.file("database.tls.key_file", db.TLS.KeyFile)
+			}
+		}
+	default:
+		v.add(fmt.Errorf("database.kind must be astra or cassandra, got %q", db.Kind))
+	}
+	v.check(db.Timeout > 0, "database.timeout must be positive, got %d", db.Timeout)
+	v.check(db.ConnectTimeout > 0, "database.connect_timeout must be positive, got %d", db.ConnectTimeout)
+	v.check(db.NumConns > 0, "database.num_conns must be positive, got %d", db.NumConns)
+	v.check(db.PageSize > 0, "database.page_size must be positive, got %d", db.PageSize)
+	v.consistency("database.read_consistency", db.ReadConsistency, true)
+	v.consistency("database.write_consistency", db.WriteConsistency, true)
+	v.serialConsistency("database.serial_consistency", db.SerialConsistency, true)
+	v.retry("database.retry", db.Retry)
+	v.speculative("database.speculative", db.Speculative)
+
+	for queryType, query := range db.Queries {
+		field := "database.queries." + queryType
+		v.check(slices.Contains([]string{"read", "scan", "write", "lwt"}, queryType), "%s: query type must be read, scan, write or lwt", field)
+		v.consistency(field+".consistency", query.Consistency, false)
+		v.serialConsistency(field+".serial_consistency", query.SerialConsistency, false)
+		v.check(query.PageSize >= 0, "%s.page_size must not be negative, got %d", field, query.PageSize)
+		if query.Retry != nil {
+			v.retry(field+".retry", *query.Retry)
+		}
+		if query.Speculative != nil {
+			v.speculative(field+".speculative", *query.Speculative)
+		}
+	}
+}
+
+// consistencies are the consistency levels queries may use, ANY is left out
+// as it only applies to writes and loses data when the coordinator fails.
+var consistencies = []string{"ONE", "TWO", "THREE", "QUORUM", "ALL", "LOCAL_QUORUM", "EACH_QUORUM", "LOCAL_ONE"}
+
+func (v *validator) consistency(field, value string, required bool) {
+	if value == "" && !required {
+		return
+	}
+	v.check(slices.Contains(consistencies, value), "%s must be one of %s, got %q", field, strings.Join(consistencies, ", "), value)
+}
+
+func (v *validator) serialConsistency(field, value string, required bool) {
+	if value == "" && !required {
+		return
+	}
+	v.check(value == "SERIAL" || value == "LOCAL_SERIAL", "%s must be SERIAL or LOCAL_SERIAL, got %q", field, value)
+}
+
+func (v *validator) retry(field string, retry DBRetry) {
+	v.check(retry.Attempts >= 0, "%s.attempts must not be negative, got %d", field, retry.Attempts)
+	if retry.Attempts > 0 {
+		v.check(retry.MinBackoff > 0, "%s.min_backoff must be positive, got %d", field, retry.MinBackoff)
+		v.check(retry.MaxBackoff >= retry.MinBackoff, "%s.max_backoff must not be below min_backoff, got %d", field, retry.MaxBackoff)
+	}
+}
+
+func (v *validator) speculative(field string, speculative DBSpeculative) {
+	v.check(speculative.Attempts >= 0, "%s.attempts must not be negative, got %d", field, speculative.Attempts)
+	if speculative.Attempts > 0 {
+		v.check(speculative.Delay > 0, "%s.delay must be positive, got %d", field, speculative.Delay)
+	}
+}
+
+// validateAdminAddress checks the admin listener is a host:port apart from
+// the public port, so the admin RPCs are never served with the public ones.
+func (c *Config) validateAdminAddress(v *validator) {
+	if c.Server.AdminAddress == "" {
+		v.add(errors.New("server.admin_address is required"))
+		return
+	}
+	_, port, err := net.SplitHostPort(c.Server.AdminAddress)
+	if err != nil {
+		v.add(fmt.Errorf("server.admin_address must be host:port: %w", err))
+		return
+	}
+	v.check(port != strconv.Itoa(c.Server.Port) && port != strconv.Itoa(c.Server.MetricsPort),
+		"server.admin_address must use a port apart from server.port and server.metrics_port, got %s", port)
+}
+
+func (c *Config) validateSecrets(v *validator) {
+	v.check(len(c.Secrets.Providers) > 0, "secrets.providers is required")
+	for _, provider := range c.Secrets.Providers {
+		switch provider {
+		case "env":
+		case "file":
+			v.required("secrets.dir", c.Secrets.Dir)
+		case "ssm":
+			v.check(strings.HasPrefix(c.Secrets.SSMPrefix, "/"), "secrets.ssm_prefix must be an absolute parameter path, got %q", c.Secrets.SSMPrefix)
+		case "encrypted":
+			v.file("secrets.encrypted_file", c.Secrets.EncryptedFile)
+		default:
+			v.add(fmt.Errorf("secrets.providers must list env, file, ssm or encrypted, got %q", provider))
+		}
+	}
+	v.check(c.Secrets.CacheTTL >= 0, "secrets.cache_ttl must not be negative, got %d", c.Secrets.CacheTTL)
+	v.check(c.Secrets.RotationInterval > 0, "secrets.rotation_interval must be positive, got %d", c.Secrets.RotationInterval)
+}
+
+func (c *Config) validatePulsar(v *validator) {
+	v.uri("pulsar.uri", c.Queue.Uri, "pulsar", "pulsar+ssl")
+	if c.Queue.AdminURL != "" {
+		v.uri("pulsar.admin_url", c.Queue.AdminURL, "http", "https")
+	}
+	v.required("pulsar.topic_name", c.Queue.TopicName)
+	for eventType, topic := range c.Queue.Routes {
+		v.check(topic != "", "pulsar.routes.%s must name a topic", eventType)
+	}
+	if c.Queue.Transactions {
+		v.check(c.Queue.TransactionTimeout > 0, "pulsar.transaction_timeout must be positive, got %d", c.Queue.TransactionTimeout)
+	}
+
+	events := c.Queue.CloudEvents
+	v.check(slices.Contains([]string{"", "binary", "structured"}, events.Mode), "pulsar.cloudevents.mode must be binary or structured, got %q", events.Mode)
+	v.check(slices.Contains([]string{"", "json", "protobuf"}, events.Format), "pulsar.cloudevents.format must be json or protobuf, got %q", events.Format)
+	v.check(events.Mode != "structured" || events.Format != "protobuf", "pulsar.cloudevents.format protobuf requires binary mode")
+}
+
+// validator collects the problems found in a config.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) add(err error) {
+	v.errs = append(v.errs, err)
+}
+
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.add(fmt.Errorf(format, args...))
+	}
+}
+
+func (v *validator) required(field, value string) {
+	v.check(value != "", "%s is required", field)
+}
+
+// uri checks value is an absolute URI with one of the schemes.
+func (v *validator) uri(field, value string, schemes ...string) {
+	if value == "" {
+		v.add(fmt.Errorf("%s is required", field))
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		v.add(fmt.Errorf("%s is not a valid URI: %w", field, err))
+		return
+	}
+	v.check(slices.Contains(schemes, u.Scheme) && u.Host != "", "%s must be a %s URI with a host, got %q", field, schemeList(schemes), value)
+}
+
+// file checks value names an existing regular file.
+func (v *validator) file(field, path string) {
+	if path == "" {
+		v.add(fmt.Errorf("%s is required", field))
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		v.add(fmt.Errorf("%s: %w", field, err))
+		return
+	}
+	v.check(info.Mode().IsRegular(), "%s: %s is not a file", field, path)
+}
+
+func schemeList(schemes []string) string {
+	list := schemes[0] + "://"
+	for _, scheme := range schemes[1:] {
+		list += " or " + scheme + "://"
+	}
+	return list
+}
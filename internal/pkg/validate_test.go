@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig returns the defaults completed with the fields they leave
+// empty, against a self-hosted cluster so no bundle file is needed.
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.Kind = "cassandra"
+	cfg.Database.Hosts = []string{"localhost"}
+	cfg.Queue.Uri = "pulsar://localhost:6650"
+	cfg.Queue.AdminURL = "http://localhost:8080"
+	cfg.Queue.TopicName = "persistent://public/default/products"
+	return cfg
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(cfg *Config)
+		// validate replaces Validate when set
+		validate func(cfg *Config) error
+		// wantErrs are the problems reported, none for a valid config
+		wantErrs []string
+	}{
+		{
+			name:   "valid",
+			mutate: func(*Config) {},
+		},
+		{
+			name: "every problem is reported",
+			mutate: func(cfg *Config) {
+				cfg.Server.Port = 0
+				cfg.Outbox.Shards = 0
+			},
+			wantErrs: []string{"server.port must be between 1 and 65535", "outbox.shards must be positive"},
+		},
+		{
+			name:     "admin address on the public port",
+			mutate:   func(cfg *Config) { cfg.Server.AdminAddress = "127.0.0.1:50051" },
+			wantErrs: []string{"server.admin_address must use a port apart from server.port"},
+		},
+		{
+			name:     "admin address without a port",
+			mutate:   func(cfg *Config) { cfg.Server.AdminAddress = "127.0.0.1" },
+			wantErrs: []string{"server.admin_address must be host:port"},
+		},
+		{
+			name:     "pulsar publisher without admin url",
+			mutate:   func(cfg *Config) { cfg.Queue.AdminURL = "" },
+			wantErrs: []string{"pulsar.admin_url is required"},
+		},
+		{
+			name: "server without a relay needs no admin url",
+			mutate: func(cfg *Config) {
+				cfg.Server.DisableRelay = true
+				cfg.Queue.AdminURL = ""
+			},
+		},
+		{
+			name: "relay needs an admin url whatever the server does",
+			mutate: func(cfg *Config) {
+				cfg.Server.DisableRelay = true
+				cfg.Queue.AdminURL = ""
+			},
+			validate: (*Config).ValidateRelay,
+			wantErrs: []string{"pulsar.admin_url is required"},
+		},
+		{
+			name:     "pulsar uri with the wrong scheme",
+			mutate:   func(cfg *Config) { cfg.Queue.Uri = "http://localhost:6650" },
+			wantErrs: []string{"pulsar.uri must be a"},
+		},
+		{
+			name: "kafka publisher ignores the pulsar block",
+			mutate: func(cfg *Config) {
+				cfg.Publisher.Kind = "kafka"
+				cfg.Publisher.Kafka.Brokers = []string{"localhost:9092"}
+				cfg.Publisher.Kafka.Topic = "products"
+				cfg.Queue = Pulsar{}
+			},
+		},
+		{
+			name: "kafka publisher with routes only",
+			mutate: func(cfg *Config) {
+				cfg.Publisher.Kind = "kafka"
+				cfg.Publisher.Kafka.Brokers = []string{"localhost:9092"}
+				cfg.Publisher.Kafka.Routes = map[string]string{"CREATE_PRODUCT": "products"}
+			},
+			wantErrs: []string{"publisher.kafka.topic is required"},
+		},
+		{
+			name: "nats publisher without a url",
+			mutate: func(cfg *Config) {
+				cfg.Publisher.Kind = "nats"
+				cfg.Publisher.NATS.Subject = "products"
+			},
+		},
+		{
+			name: "nats publisher with routes only and a bad url",
+			mutate: func(cfg *Config) {
+				cfg.Publisher.Kind = "nats"
+				cfg.Publisher.NATS.URL = "http://localhost:4222"
+				cfg.Publisher.NATS.Routes = map[string]string{"CREATE_PRODUCT": "products"}
+			},
+			wantErrs: []string{"publisher.nats.url must be a", "publisher.nats.subject is required"},
+		},
+		{
+			name: "kafka publisher with a projector subscription checks pulsar",
+			mutate: func(cfg *Config) {
+				cfg.Publisher.Kind = "kafka"
+				cfg.Publisher.Kafka.Brokers = []string{"localhost:9092"}
+				cfg.Publisher.Kafka.Topic = "products"
+				cfg.Queue = Pulsar{SubscriptionName: "projection"}
+			},
+			wantErrs: []string{"pulsar.uri is required", "pulsar.topic_name is required"},
+		},
+		{
+			name:     "projector requires a subscription",
+			mutate:   func(*Config) {},
+			validate: (*Config).ValidateProjector,
+			wantErrs: []string{"pulsar.subscriptionName is required"},
+		},
+		{
+			name: "projector checks pulsar whatever the publisher",
+			mutate: func(cfg *Config) {
+				cfg.Publisher.Kind = "stdout"
+				cfg.Queue = Pulsar{}
+			},
+			validate: (*Config).ValidateProjector,
+			wantErrs: []string{"pulsar.subscriptionName is required", "pulsar.uri is required", "pulsar.topic_name is required"},
+		},
+		{
+			name: "projector needs no admin url",
+			mutate: func(cfg *Config) {
+				cfg.Queue.SubscriptionName = "projection"
+				cfg.Queue.AdminURL = ""
+			},
+			validate: (*Config).ValidateProjector,
+		},
+		{
+			name:     "unknown publisher",
+			mutate:   func(cfg *Config) { cfg.Publisher.Kind = "sqs" },
+			wantErrs: []string{"publisher.kind must be one of"},
+		},
+		{
+			name: "ssm secrets need an absolute prefix",
+			mutate: func(cfg *Config) {
+				cfg.Secrets.Providers = []string{"env", "ssm"}
+				cfg.Secrets.SSMPrefix = "myapp"
+			},
+			wantErrs: []string{"secrets.ssm_prefix must be an absolute parameter path"},
+		},
+		{
+			name: "missing bundle",
+			mutate: func(cfg *Config) {
+				cfg.Database = Default().Database
+				cfg.Database.Path = "./missing.zip"
+			},
+			wantErrs: []string{"database.path"},
+		},
+		{
+			name:     "unknown consistency",
+			mutate:   func(cfg *Config) { cfg.Database.ReadConsistency = "ANY" },
+			wantErrs: []string{"database.read_consistency must be one of"},
+		},
+		{
+			name:     "max backoff below retry backoff",
+			mutate:   func(cfg *Config) { cfg.Outbox.MaxBackoff = 1 },
+			wantErrs: []string{"outbox.max_backoff must not be below outbox.retry_backoff"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+
+			validate := (*Config).Validate
+			if tt.validate != nil {
+				validate = tt.validate
+			}
+			err := validate(cfg)
+
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v, want none", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %q", tt.wantErrs)
+			}
+
+			problems := strings.Split(err.Error(), "\n")
+			if len(problems) != len(tt.wantErrs) {
+				t.Errorf("Validate() reported %d problems, want %d:\n%v", len(problems), len(tt.wantErrs), err)
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want it to contain %q", err, want)
+				}
+			}
+		})
+	}
+}
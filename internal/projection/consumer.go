@@ -0,0 +1,92 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/yaninyzwitty/grpc-products-service/internal/events"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Consumer feeds the messages of a subscription to a Projector. Messages are
+// acknowledged once applied and nacked for redelivery when applying fails;
+// the subscription cursor is the resume position.
+type Consumer struct {
+	consumer  pulsar.Consumer
+	projector *Projector
+}
+
+// NewConsumer initializes and returns a Consumer applying the messages of
+// the subscription to the store's read models.
+func NewConsumer(consumer pulsar.Consumer, projectionStore store.ProjectionStore) *Consumer {
+	return &Consumer{
+		consumer:  consumer,
+		projector: NewProjector(projectionStore),
+	}
+}
+
+// Run applies messages until ctx is done.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case message, ok := <-c.consumer.Chan():
+			if !ok {
+				return errors.New("projection consumer was closed")
+			}
+			c.handle(ctx, message.Message)
+		}
+	}
+}
+
+// handle applies the message within a span continuing the trace the relay
+// sent in the message properties.
+func (c *Consumer) handle(ctx context.Context, message pulsar.Message) {
+	ctx, span := tracing.Tracer().Start(tracing.Extract(ctx, message.Properties()), "process "+message.Topic(),
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "pulsar"),
+			semconv.MessagingOperationTypeDeliver,
+			semconv.MessagingDestinationName(message.Topic()),
+			semconv.MessagingMessageID(message.ID().String()),
+		),
+	)
+	defer span.End()
+
+	event, err := events.Decode(message.Payload(), message.Properties())
+	if err != nil {
+		// Redelivering cannot fix a malformed message
+		slog.Error("skipping undecodable event", "message_id", message.ID().String(), "error", err)
+		span.SetStatus(codes.Error, err.Error())
+		c.ack(message)
+		return
+	}
+
+	applied, err := c.projector.Apply(ctx, event)
+	if err != nil {
+		slog.Error("failed to apply event", "event_id", event.EventId, "error", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		c.consumer.Nack(message)
+		return
+	}
+	if !applied {
+		slog.Debug("event did not change the projection", "event_id", event.EventId)
+	}
+
+	c.ack(message)
+}
+
+func (c *Consumer) ack(message pulsar.Message) {
+	if err := c.consumer.Ack(message); err != nil {
+		slog.Error("failed to acknowledge message", "message_id", message.ID().String(), "error", err)
+	}
+}
@@ -0,0 +1,116 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+)
+
+// Projector applies product events to the read models.
+type Projector struct {
+	store store.ProjectionStore
+}
+
+// NewProjector returns a Projector maintaining the read models of the store.
+func NewProjector(projectionStore store.ProjectionStore) *Projector {
+	return &Projector{store: projectionStore}
+}
+
+// Apply applies the event and reports whether it changed the read models;
+// events applied before or older than the view they target are skipped. The
+// event is recorded as applied in the same write as its changes, so an event
+// that failed or was interrupted is applied again on redelivery, and every
+// change is an upsert so applying it twice leaves the same read models.
+func (p *Projector) Apply(ctx context.Context, event *pb.ProductEvent) (bool, error) {
+	applied, err := p.store.ProjectionEventApplied(ctx, event.EventId)
+	if err != nil {
+		return false, err
+	}
+	if applied {
+		return false, nil
+	}
+
+	switch e := event.Event.(type) {
+	case *pb.ProductEvent_ProductCreated:
+		return p.putProduct(ctx, e.ProductCreated.GetProduct(), event)
+	case *pb.ProductEvent_ProductUpdated:
+		return p.putProduct(ctx, e.ProductUpdated.GetProduct(), event)
+	case *pb.ProductEvent_ProductDeleted:
+		return p.deleteProduct(ctx, e.ProductDeleted.GetProductId(), event)
+	case *pb.ProductEvent_CategoryCreated, *pb.ProductEvent_CategoryUpdated:
+		// Counts only change with the products of a category
+		return false, nil
+	case *pb.ProductEvent_CategoryDeleted:
+		return p.deleteCategory(ctx, e.CategoryDeleted.GetCategoryId(), event)
+	default:
+		slog.Warn("skipping unknown product event", "event_id", event.EventId)
+		return false, nil
+	}
+}
+
+// deleteCategory clears the product count of the category. The product views
+// are kept on purpose: every product leaves the read models through its own
+// ProductDeleted event, which carries the sequence that orders it against the
+// product's other events, while this event is ordered by the category alone.
+func (p *Projector) deleteCategory(ctx context.Context, categoryID int64, event *pb.ProductEvent) (bool, error) {
+	if err := p.store.DeleteCategoryProducts(ctx, categoryID, event.EventId); err != nil {
+		return false, fmt.Errorf("failed to delete category products: %w", err)
+	}
+	return true, nil
+}
+
+// putProduct upserts the views of the product and moves it between category
+// counts when it is new or changed category.
+func (p *Projector) putProduct(ctx context.Context, product *pb.Product, event *pb.ProductEvent) (bool, error) {
+	if product == nil {
+		return false, errors.New("event has no product")
+	}
+
+	previous, err := p.productView(ctx, product.Id)
+	if err != nil {
+		return false, err
+	}
+	if previous != nil && stale(event.Sequence, previous.Sequence) {
+		return false, nil
+	}
+
+	view := store.ProductView{Product: product, Sequence: event.Sequence}
+	if err := p.store.PutProductView(ctx, view, previous, event.EventId); err != nil {
+		return false, fmt.Errorf("failed to put product view: %w", err)
+	}
+	return true, nil
+}
+
+func (p *Projector) deleteProduct(ctx context.Context, id int64, event *pb.ProductEvent) (bool, error) {
+	previous, err := p.productView(ctx, id)
+	if err != nil || previous == nil {
+		return false, err
+	}
+	if stale(event.Sequence, previous.Sequence) {
+		return false, nil
+	}
+
+	if err := p.store.DeleteProductView(ctx, *previous, event.EventId); err != nil {
+		return false, fmt.Errorf("failed to delete product view: %w", err)
+	}
+	return true, nil
+}
+
+// productView returns the current view of the product, nil if there is none.
+func (p *Projector) productView(ctx context.Context, id int64) (*store.ProductView, error) {
+	view, err := p.store.GetProductView(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	return view, err
+}
+
+// stale reports whether an event is older than the view it targets; events
+// written before sequences were introduced always apply.
+func stale(sequence, applied uint64) bool {
+	return sequence != 0 && sequence <= applied
+}
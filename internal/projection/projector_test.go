@@ -0,0 +1,171 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+)
+
+func productCreated(id string, sequence uint64, product *pb.Product) *pb.ProductEvent {
+	return &pb.ProductEvent{
+		EventId:  id,
+		Sequence: sequence,
+		Event: &pb.ProductEvent_ProductCreated{
+			ProductCreated: &pb.ProductCreated{Product: product},
+		},
+	}
+}
+
+func productUpdated(id string, sequence uint64, product *pb.Product) *pb.ProductEvent {
+	return &pb.ProductEvent{
+		EventId:  id,
+		Sequence: sequence,
+		Event: &pb.ProductEvent_ProductUpdated{
+			ProductUpdated: &pb.ProductUpdated{Product: product},
+		},
+	}
+}
+
+func productDeleted(id string, sequence uint64, productID, categoryID int64) *pb.ProductEvent {
+	return &pb.ProductEvent{
+		EventId:  id,
+		Sequence: sequence,
+		Event: &pb.ProductEvent_ProductDeleted{
+			ProductDeleted: &pb.ProductDeleted{ProductId: productID, CategoryId: categoryID},
+		},
+	}
+}
+
+func categoryDeleted(id string, categoryID int64) *pb.ProductEvent {
+	return &pb.ProductEvent{
+		EventId:  id,
+		Sequence: 2,
+		Event: &pb.ProductEvent_CategoryDeleted{
+			CategoryDeleted: &pb.CategoryDeleted{CategoryId: categoryID},
+		},
+	}
+}
+
+func TestProjectorApply(t *testing.T) {
+	lamp := &pb.Product{Id: 1, Name: "lamp", Price: 25, CategoryId: 10}
+	chair := &pb.Product{Id: 2, Name: "chair", Price: 60, CategoryId: 10}
+	floorLamp := &pb.Product{Id: 1, Name: "floor lamp", Price: 40, CategoryId: 10}
+	movedLamp := &pb.Product{Id: 1, Name: "lamp", Price: 25, CategoryId: 20}
+
+	tests := []struct {
+		name   string
+		events []*pb.ProductEvent
+		// wantApplied is the result of applying each event
+		wantApplied []bool
+
+		wantView   *pb.Product // view of product 1, nil when there is none
+		wantCounts map[int64]int64
+	}{
+		{
+			name:        "created",
+			events:      []*pb.ProductEvent{productCreated("e1", 1, lamp)},
+			wantApplied: []bool{true},
+			wantView:    lamp,
+			wantCounts:  map[int64]int64{10: 1},
+		},
+		{
+			name:        "redelivered event is skipped",
+			events:      []*pb.ProductEvent{productCreated("e1", 1, lamp), productCreated("e1", 1, lamp)},
+			wantApplied: []bool{true, false},
+			wantView:    lamp,
+			wantCounts:  map[int64]int64{10: 1},
+		},
+		{
+			name:        "updates do not count twice",
+			events:      []*pb.ProductEvent{productCreated("e1", 1, lamp), productUpdated("e2", 2, floorLamp)},
+			wantApplied: []bool{true, true},
+			wantView:    floorLamp,
+			wantCounts:  map[int64]int64{10: 1},
+		},
+		{
+			name:        "older update is skipped",
+			events:      []*pb.ProductEvent{productUpdated("e2", 2, floorLamp), productCreated("e1", 1, lamp)},
+			wantApplied: []bool{true, false},
+			wantView:    floorLamp,
+			wantCounts:  map[int64]int64{10: 1},
+		},
+		{
+			name:        "category change moves the count",
+			events:      []*pb.ProductEvent{productCreated("e1", 1, lamp), productUpdated("e2", 2, movedLamp)},
+			wantApplied: []bool{true, true},
+			wantView:    movedLamp,
+			wantCounts:  map[int64]int64{10: 0, 20: 1},
+		},
+		{
+			name: "deleted",
+			events: []*pb.ProductEvent{
+				productCreated("e1", 1, lamp),
+				productCreated("e3", 1, chair),
+				productDeleted("e2", 2, lamp.Id, lamp.CategoryId),
+			},
+			wantApplied: []bool{true, true, true},
+			wantCounts:  map[int64]int64{10: 1},
+		},
+		{
+			name:        "delete of an unknown product is skipped",
+			events:      []*pb.ProductEvent{productDeleted("e2", 2, lamp.Id, lamp.CategoryId)},
+			wantApplied: []bool{false},
+			wantCounts:  map[int64]int64{10: 0},
+		},
+		{
+			name: "category deleted keeps the product views",
+			events: []*pb.ProductEvent{
+				productCreated("e1", 1, lamp),
+				productCreated("e3", 1, chair),
+				categoryDeleted("e4", 10),
+				categoryDeleted("e4", 10),
+			},
+			wantApplied: []bool{true, true, true, false},
+			wantView:    lamp,
+			wantCounts:  map[int64]int64{10: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s := store.NewMemoryStore()
+			projector := NewProjector(s)
+
+			for i, event := range tt.events {
+				applied, err := projector.Apply(ctx, event)
+				if err != nil {
+					t.Fatalf("Apply(%s) error = %v", event.EventId, err)
+				}
+				if applied != tt.wantApplied[i] {
+					t.Errorf("Apply(%s) = %v, want %v", event.EventId, applied, tt.wantApplied[i])
+				}
+			}
+
+			view, err := s.GetProductView(ctx, 1)
+			switch {
+			case tt.wantView == nil:
+				if !errors.Is(err, store.ErrNotFound) {
+					t.Errorf("view of product 1 = %v, %v, want none", view, err)
+				}
+			case err != nil:
+				t.Errorf("failed to get view of product 1: %v", err)
+			case view.Product.Name != tt.wantView.Name || view.Product.CategoryId != tt.wantView.CategoryId:
+				t.Errorf("view of product 1 = %v, want %v", view.Product, tt.wantView)
+			}
+
+			for categoryID, want := range tt.wantCounts {
+				count, err := s.CountCategoryProducts(ctx, categoryID)
+				if err != nil {
+					t.Fatalf("failed to count category products: %v", err)
+				}
+				if count != want {
+					t.Errorf("category %d has %d products, want %d", categoryID, count, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// FileConfig holds the configuration for the local file sink.
+type FileConfig struct {
+	Path string
+}
+
+// record is the JSON line written for every message. JSON payloads are
+// embedded as is, anything else is base64 encoded.
+type record struct {
+	Id            string            `json:"id"`
+	EventType     string            `json:"event_type"`
+	Key           string            `json:"key"`
+	SequenceID    int64             `json:"sequence_id,omitempty"`
+	Properties    map[string]string `json:"properties,omitempty"`
+	Payload       json.RawMessage   `json:"payload,omitempty"`
+	PayloadBase64 []byte            `json:"payload_base64,omitempty"`
+}
+
+// File writes every message as a JSON line, for local runs and tests.
+type File struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// NewFile opens the file for appending, creating it if needed.
+func NewFile(cfg *FileConfig) (*File, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("file publisher requires a path")
+	}
+
+	f, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", cfg.Path, err)
+	}
+	return &File{w: f, closer: f}, nil
+}
+
+// NewStdout returns a publisher writing JSON lines to standard output.
+func NewStdout() *File {
+	return &File{w: os.Stdout}
+}
+
+func (f *File) Publish(_ context.Context, msg *Message) error {
+	rec := record{
+		Id:         msg.Id,
+		EventType:  msg.EventType,
+		Key:        msg.Key,
+		SequenceID: msg.SequenceID,
+		Properties: msg.Properties,
+	}
+	if json.Valid(msg.Payload) {
+		rec.Payload = msg.Payload
+	} else {
+		rec.PayloadBase64 = msg.Payload
+	}
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	return nil
+}
+
+func (f *File) Close() error {
+	if f.closer == nil {
+		return nil
+	}
+	return f.closer.Close()
+}
@@ -0,0 +1,122 @@
+package publisher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testMessages = []*Message{
+	{
+		Id:         "8d3c7a4e-adce-11f0-8de9-0242ac120002",
+		EventType:  "CREATE_PRODUCT",
+		Key:        "7",
+		Payload:    []byte(`{"id":"7","name":"lamp"}`),
+		Properties: map[string]string{"content-type": "application/json"},
+	},
+	{
+		Id:         "8d3c7a4e-adce-11f0-8de9-0242ac120003",
+		EventType:  "UPDATE_PRODUCT",
+		Key:        "7",
+		SequenceID: 2,
+		Payload:    []byte{0x0a, 0xff},
+	},
+}
+
+// readRecords decodes the JSON lines written by a File publisher.
+func readRecords(t *testing.T, r io.Reader) []record {
+	t.Helper()
+
+	var records []record
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var rec record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatalf("failed to unmarshal line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("failed to read lines: %v", err)
+	}
+	return records
+}
+
+func checkRecords(t *testing.T, records []record, messages []*Message) {
+	t.Helper()
+
+	if len(records) != len(messages) {
+		t.Fatalf("%d records written, want %d", len(records), len(messages))
+	}
+	for i, msg := range messages {
+		rec := records[i]
+		if rec.Id != msg.Id || rec.EventType != msg.EventType || rec.Key != msg.Key || rec.SequenceID != msg.SequenceID {
+			t.Errorf("record %d = %+v, want the fields of %+v", i, rec, msg)
+		}
+		if rec.Properties["content-type"] != msg.Properties["content-type"] {
+			t.Errorf("record %d properties = %v, want %v", i, rec.Properties, msg.Properties)
+		}
+	}
+
+	// JSON payloads are embedded, anything else is base64 encoded
+	if string(records[0].Payload) != string(messages[0].Payload) || records[0].PayloadBase64 != nil {
+		t.Errorf("json payload written as %s / %x", records[0].Payload, records[0].PayloadBase64)
+	}
+	if records[1].Payload != nil || string(records[1].PayloadBase64) != string(messages[1].Payload) {
+		t.Errorf("binary payload written as %s / %x", records[1].Payload, records[1].PayloadBase64)
+	}
+}
+
+func TestFilePublish(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+
+	// every publisher appends to what earlier ones wrote
+	for _, msg := range testMessages {
+		pub, err := NewFile(&FileConfig{Path: path})
+		if err != nil {
+			t.Fatalf("NewFile() error = %v", err)
+		}
+		if err := pub.Publish(context.Background(), msg); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if err := pub.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	checkRecords(t, readRecords(t, f), testMessages)
+}
+
+func TestStdoutPublish(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	pub := NewStdout()
+	os.Stdout = stdout
+
+	for _, msg := range testMessages {
+		if err := pub.Publish(context.Background(), msg); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	// closing the publisher leaves standard output open
+	if err := pub.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	w.Close()
+
+	checkRecords(t, readRecords(t, r), testMessages)
+}
@@ -0,0 +1,66 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig holds the configuration for publishing to a Kafka-protocol broker.
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string            // fallback topic for event types without a route
+	Routes  map[string]string // event type to topic
+}
+
+// Kafka publishes to a Kafka-protocol broker, such as Kafka, Redpanda or
+// Pulsar with KoP, waiting for every in-sync replica to acknowledge.
+type Kafka struct {
+	writer *kafka.Writer
+	routes Routes
+}
+
+// NewKafka initializes and returns a Kafka publisher. Messages are
+// partitioned with murmur2 on their key, like the Java client.
+func NewKafka(cfg *KafkaConfig) (*Kafka, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("kafka publisher requires at least one broker")
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka publisher requires a topic")
+	}
+
+	return &Kafka{
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(cfg.Brokers...),
+			Balancer:     &kafka.Murmur2Balancer{},
+			RequiredAcks: kafka.RequireAll,
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		routes: Routes{Default: cfg.Topic, ByType: cfg.Routes},
+	}, nil
+}
+
+func (k *Kafka) Publish(ctx context.Context, msg *Message) error {
+	headers := make([]kafka.Header, 0, len(msg.Properties))
+	for key, value := range msg.Properties {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+
+	err := k.writer.WriteMessages(ctx, kafka.Message{
+		Topic:   k.routes.TopicFor(msg.EventType),
+		Key:     []byte(msg.Key),
+		Value:   msg.Payload,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	return nil
+}
+
+func (k *Kafka) Close() error {
+	return k.writer.Close()
+}
@@ -0,0 +1,84 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NATSConfig holds the configuration for publishing to NATS.
+type NATSConfig struct {
+	URL     string
+	Subject string            // fallback subject for event types without a route
+	Routes  map[string]string // event type to subject
+	// JetStream publishes to a stream and waits for its acknowledgement; the
+	// outbox id is sent as Nats-Msg-Id so the stream drops replays.
+	JetStream bool
+}
+
+// NATS publishes to NATS core subjects or JetStream streams.
+type NATS struct {
+	conn   *nats.Conn
+	js     nats.JetStreamContext
+	routes Routes
+}
+
+// NewNATS connects to NATS and returns a publisher.
+func NewNATS(cfg *NATSConfig) (*NATS, error) {
+	if cfg.Subject == "" {
+		return nil, fmt.Errorf("nats publisher requires a subject")
+	}
+
+	url := cfg.URL
+	if url == "" {
+		url = nats.DefaultURL
+	}
+
+	conn, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+
+	n := &NATS{
+		conn:   conn,
+		routes: Routes{Default: cfg.Subject, ByType: cfg.Routes},
+	}
+	if cfg.JetStream {
+		if n.js, err = conn.JetStream(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+		}
+	}
+
+	return n, nil
+}
+
+// Publish sends the message with its properties as headers. Core NATS has no
+// acknowledgements, so the connection is flushed to at least reach the server.
+func (n *NATS) Publish(ctx context.Context, msg *Message) error {
+	message := nats.NewMsg(n.routes.TopicFor(msg.EventType))
+	message.Data = msg.Payload
+	for key, value := range msg.Properties {
+		message.Header.Set(key, value)
+	}
+
+	if n.js != nil {
+		if _, err := n.js.PublishMsg(message, nats.MsgId(msg.Id), nats.Context(ctx)); err != nil {
+			return fmt.Errorf("failed to publish message: %w", err)
+		}
+		return nil
+	}
+
+	if err := n.conn.PublishMsg(message); err != nil {
+		return fmt.Errorf("failed to publish message: %w", err)
+	}
+	if err := n.conn.FlushWithContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush message: %w", err)
+	}
+	return nil
+}
+
+func (n *NATS) Close() error {
+	return n.conn.Drain()
+}
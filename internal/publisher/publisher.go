@@ -0,0 +1,108 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/queue"
+)
+
+// Kind selects the sink events are published to.
+type Kind string
+
+const (
+	KindPulsar Kind = "pulsar"
+	KindKafka  Kind = "kafka"
+	KindNATS   Kind = "nats"
+	KindFile   Kind = "file"
+	KindStdout Kind = "stdout"
+)
+
+// Message is an encoded outbox event ready to be published.
+type Message struct {
+	Id          string // outbox id, used for deduplication where the sink supports it
+	EventType   string // outbox event type, routes the message to its topic
+	Key         string // ordering key, the subject of the event
+	Payload     []byte
+	Properties  map[string]string
+	SequenceID  int64       // producer sequence id, zero when unset
+	Transaction Transaction // nil outside of a transaction
+}
+
+// Publisher publishes encoded events and returns once the sink acknowledged them.
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+	Close() error
+}
+
+// Sequencer is implemented by publishers whose broker deduplicates messages
+// by producer sequence id.
+type Sequencer interface {
+	// LastSequenceID returns the topic events of the type are published to
+	// and the highest sequence id the broker acknowledged on it, or -1.
+	LastSequenceID(ctx context.Context, eventType string) (string, int64, error)
+	// PublishAsync enqueues the message on its producer and returns a
+	// function waiting for the broker to acknowledge it. Messages enqueued
+	// one after the other reach the broker in that order.
+	PublishAsync(ctx context.Context, msg *Message) (func() error, error)
+}
+
+// Transaction groups messages that become visible to consumers together.
+type Transaction interface {
+	Commit(ctx context.Context) error
+	Abort(ctx context.Context) error
+}
+
+// Transactional is implemented by publishers that support transactions.
+type Transactional interface {
+	BeginTransaction(timeout time.Duration) (Transaction, error)
+}
+
+// Releaser is implemented by publishers holding broker-side identities, such
+// as named producers, that the next relay leader needs to claim.
+type Releaser interface {
+	Release()
+}
+
+// Routes maps outbox event types to topics.
+type Routes struct {
+	Default string            // topic for event types without a route
+	ByType  map[string]string // event type to topic
+}
+
+// TopicFor returns the topic events of the given type are published to.
+func (r Routes) TopicFor(eventType string) string {
+	if topic, ok := r.ByType[eventType]; ok && topic != "" {
+		return topic
+	}
+	return r.Default
+}
+
+// Config holds the configuration of every sink, only the one selected by
+// Kind is used.
+type Config struct {
+	Kind   Kind
+	Pulsar *queue.PulsarConfig
+	Kafka  KafkaConfig
+	NATS   NATSConfig
+	File   FileConfig
+}
+
+// New creates the publisher selected by cfg.Kind, Pulsar when unset.
+func New(ctx context.Context, cfg *Config) (Publisher, error) {
+	switch cfg.Kind {
+	case "", KindPulsar:
+		return NewPulsar(ctx, cfg.Pulsar)
+	case KindKafka:
+		return NewKafka(&cfg.Kafka)
+	case KindNATS:
+		return NewNATS(&cfg.NATS)
+	case KindFile:
+		return NewFile(&cfg.File)
+	case KindStdout:
+		return NewStdout(), nil
+	default:
+		return nil, fmt.Errorf("unknown publisher kind %q", cfg.Kind)
+	}
+}
@@ -0,0 +1,61 @@
+package publisher
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRoutesTopicFor(t *testing.T) {
+	routes := Routes{
+		Default: "products",
+		ByType: map[string]string{
+			"CREATE_CATEGORY": "categories",
+			"DELETE_CATEGORY": "",
+		},
+	}
+
+	tests := []struct {
+		eventType string
+		want      string
+	}{
+		{eventType: "CREATE_CATEGORY", want: "categories"},
+		{eventType: "CREATE_PRODUCT", want: "products"},
+		{eventType: "DELETE_CATEGORY", want: "products"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.eventType, func(t *testing.T) {
+			if got := routes.TopicFor(tt.eventType); got != tt.want {
+				t.Errorf("TopicFor(%q) = %q, want %q", tt.eventType, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{name: "stdout", cfg: &Config{Kind: KindStdout}},
+		{name: "file", cfg: &Config{Kind: KindFile, File: FileConfig{Path: t.TempDir() + "/events.jsonl"}}},
+		{name: "file without a path", cfg: &Config{Kind: KindFile}, wantErr: true},
+		{name: "pulsar without a config", cfg: &Config{}, wantErr: true},
+		{name: "unknown kind", cfg: &Config{Kind: "sqs"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pub, err := New(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				if err := pub.Close(); err != nil {
+					t.Errorf("Close() error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/yaninyzwitty/grpc-products-service/internal/queue"
+)
+
+// Pulsar publishes to the topics routed by the Pulsar configuration. It
+// supports broker deduplication and transactions.
+type Pulsar struct {
+	client pulsar.Client
+	router *topicRouter
+}
+
+var (
+	_ Sequencer     = (*Pulsar)(nil)
+	_ Transactional = (*Pulsar)(nil)
+	_ Releaser      = (*Pulsar)(nil)
+)
+
+// NewPulsar checks the producer schema against every routed topic, failing
+// fast when the deployed schema is incompatible, and connects to Pulsar.
+func NewPulsar(ctx context.Context, cfg *queue.PulsarConfig) (*Pulsar, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("pulsar publisher is not configured")
+	}
+
+	if err := cfg.CheckSchemaCompatibility(); err != nil {
+		return nil, err
+	}
+
+	client, err := cfg.CreatePulsarConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Pulsar{
+		client: client,
+		router: newTopicRouter(cfg, client),
+	}, nil
+}
+
+// Publish sends the message keyed by its subject, so key-based routing keeps
+// every product on one partition and key-shared consumers see it in order.
+func (p *Pulsar) Publish(ctx context.Context, msg *Message) error {
+	wait, err := p.PublishAsync(ctx, msg)
+	if err != nil {
+		return err
+	}
+	return wait()
+}
+
+// PublishAsync enqueues the message on its producer, which sends messages in
+// the order they were enqueued.
+func (p *Pulsar) PublishAsync(ctx context.Context, msg *Message) (func() error, error) {
+	producer, err := p.router.ProducerFor(ctx, msg.EventType)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &pulsar.ProducerMessage{
+		Key:         msg.Key,
+		OrderingKey: msg.Key,
+		Payload:     msg.Payload,
+		Properties:  msg.Properties,
+	}
+	if msg.SequenceID > 0 {
+		message.SequenceID = &msg.SequenceID
+	}
+	if msg.Transaction != nil {
+		txn, ok := msg.Transaction.(pulsar.Transaction)
+		if !ok {
+			return nil, fmt.Errorf("transaction was not started by the pulsar publisher")
+		}
+		message.Transaction = txn
+	}
+
+	// send payload asynchronously
+	messageChan := make(chan error, 1)
+	producer.SendAsync(ctx, message, func(_ pulsar.MessageID, _ *pulsar.ProducerMessage, err error) {
+		messageChan <- err
+		close(messageChan)
+	})
+
+	return func() error {
+		select {
+		case err, ok := <-messageChan:
+			if !ok {
+				return nil // Channel closed normally
+			}
+			if err != nil {
+				return fmt.Errorf("failed to publish message: %w", err)
+			}
+		case <-ctx.Done():
+			return fmt.Errorf("context canceled while publishing message")
+		}
+		return nil
+	}, nil
+}
+
+func (p *Pulsar) LastSequenceID(ctx context.Context, eventType string) (string, int64, error) {
+	producer, err := p.router.ProducerFor(ctx, eventType)
+	if err != nil {
+		return "", 0, err
+	}
+	return producer.Topic(), producer.LastSequenceID(), nil
+}
+
+func (p *Pulsar) BeginTransaction(timeout time.Duration) (Transaction, error) {
+	return p.router.NewTransaction(timeout)
+}
+
+// Release closes the producers, they are created again on the next publish.
+func (p *Pulsar) Release() {
+	p.router.Close()
+}
+
+func (p *Pulsar) Close() error {
+	p.router.Close()
+	p.client.Close()
+	return nil
+}
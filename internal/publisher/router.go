@@ -0,0 +1,68 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/yaninyzwitty/grpc-products-service/internal/queue"
+)
+
+// topicRouter publishes to the topic routed for each event type, creating
+// the producer of a topic on first use.
+type topicRouter struct {
+	cfg    *queue.PulsarConfig
+	client pulsar.Client
+	routes Routes
+
+	mu        sync.Mutex
+	producers map[string]pulsar.Producer
+}
+
+// newTopicRouter initializes and returns a topicRouter using the given client.
+func newTopicRouter(cfg *queue.PulsarConfig, client pulsar.Client) *topicRouter {
+	return &topicRouter{
+		cfg:       cfg,
+		client:    client,
+		routes:    Routes{Default: cfg.TopicName, ByType: cfg.Routes},
+		producers: make(map[string]pulsar.Producer),
+	}
+}
+
+// ProducerFor returns the producer of the topic routed for the event type.
+// A producer that failed to be created is retried on the next call.
+func (r *topicRouter) ProducerFor(ctx context.Context, eventType string) (pulsar.Producer, error) {
+	topic := r.routes.TopicFor(eventType)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if producer, ok := r.producers[topic]; ok {
+		return producer, nil
+	}
+
+	producer, err := r.cfg.CreateTopicProducer(r.client, topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create producer for topic %s: %w", topic, err)
+	}
+	r.producers[topic] = producer
+	return producer, nil
+}
+
+// NewTransaction starts a transaction on the router's client.
+func (r *topicRouter) NewTransaction(timeout time.Duration) (pulsar.Transaction, error) {
+	return r.client.NewTransaction(timeout)
+}
+
+// Close flushes and closes every producer created so far.
+func (r *topicRouter) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for topic, producer := range r.producers {
+		producer.Close()
+		delete(r.producers, topic)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
 
 	"github.com/apache/pulsar-client-go/pulsar"
 )
@@ -12,30 +13,52 @@ import (
 type PulsarMethods interface {
 	CreatePulsarConnection(ctx context.Context) (pulsar.Client, error)
 	CreatePulsarProducer(ctx context.Context, client pulsar.Client) (pulsar.Producer, error)
-	CreatePulsarConsumer(ctx context.Context, client pulsar.Client, consumerTopic string) (pulsar.Consumer, error)
+	CheckSchemaCompatibility() error
+	CreatePulsarConsumer(ctx context.Context, client pulsar.Client, topics ...string) (pulsar.Consumer, error)
 }
 
 // PulsarConfig holds the configuration for the Pulsar connection
 type PulsarConfig struct {
-	URI       string
-	Token     string
-	TopicName string
+	URI      string
+	AdminURL string // web service url used for schema checks
+	// Token returns the current token, it is called on every authentication
+	// so rotated tokens apply without a restart.
+	Token     func() (string, error)
+	TopicName string            // fallback topic for event types without a route
+	Routes    map[string]string // event type to topic
+	Schema    pulsar.Schema     // nil for a schemaless producer
+
+	SubscriptionName string // subscription used by consumers
+
+	// ProducerName names every producer so the broker can deduplicate by
+	// sequence id; only one producer per name and topic may be connected.
+	ProducerName string
+	Transactions bool
 }
 
 // NewPulsar initializes and returns a PulsarConfig instance that implements PulsarMethods
 func NewPulsar(cfg *PulsarConfig) PulsarMethods {
 	return &PulsarConfig{
 		URI:       cfg.URI,
+		AdminURL:  cfg.AdminURL,
 		Token:     cfg.Token,
 		TopicName: cfg.TopicName,
+		Routes:    cfg.Routes,
+		Schema:    cfg.Schema,
+
+		SubscriptionName: cfg.SubscriptionName,
+
+		ProducerName: cfg.ProducerName,
+		Transactions: cfg.Transactions,
 	}
 }
 
 // CreatePulsarConnection establishes a connection to the Pulsar server
 func (c *PulsarConfig) CreatePulsarConnection(ctx context.Context) (pulsar.Client, error) {
 	clientOptions := pulsar.ClientOptions{
-		URL:            c.URI,
-		Authentication: pulsar.NewAuthenticationToken(c.Token),
+		URL:               c.URI,
+		Authentication:    pulsar.NewAuthenticationTokenFromSupplier(c.Token),
+		EnableTransaction: c.Transactions,
 	}
 
 	client, err := pulsar.NewClient(clientOptions)
@@ -48,10 +71,21 @@ func (c *PulsarConfig) CreatePulsarConnection(ctx context.Context) (pulsar.Clien
 	return client, nil
 }
 
-// CreatePulsarProducer creates a new producer for a specified topic
+// CreatePulsarProducer creates a new producer for the fallback topic
 func (c *PulsarConfig) CreatePulsarProducer(ctx context.Context, client pulsar.Client) (pulsar.Producer, error) {
+	return c.CreateTopicProducer(client, c.TopicName)
+}
+
+// CreateTopicProducer creates a producer for the topic. Messages are routed
+// and batched by key so all messages with the same key land on one partition
+// in publish order; murmur3 keeps routing consistent with other clients.
+func (c *PulsarConfig) CreateTopicProducer(client pulsar.Client, topic string) (pulsar.Producer, error) {
 	producerOptions := pulsar.ProducerOptions{
-		Topic: c.TopicName,
+		Topic:              topic,
+		Name:               c.ProducerName,
+		Schema:             c.Schema,
+		HashingScheme:      pulsar.Murmur3_32Hash,
+		BatcherBuilderType: pulsar.KeyBasedBatchBuilder,
 	}
 
 	producer, err := client.CreateProducer(producerOptions)
@@ -59,15 +93,35 @@ func (c *PulsarConfig) CreatePulsarProducer(ctx context.Context, client pulsar.C
 		return nil, fmt.Errorf("failed to create Pulsar producer: %w", err)
 	}
 
-	slog.Info("Pulsar producer created successfully", "topic", c.TopicName)
+	slog.Info("Pulsar producer created successfully", "topic", topic)
 
 	return producer, nil
 }
 
-func (c *PulsarConfig) CreatePulsarConsumer(ctx context.Context, client pulsar.Client, consumerTopic string) (pulsar.Consumer, error) {
+// Topics returns the fallback topic and every routed topic, without duplicates.
+func (c *PulsarConfig) Topics() []string {
+	topics := []string{c.TopicName}
+	for _, topic := range c.Routes {
+		if topic != "" && !slices.Contains(topics, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	slices.Sort(topics[1:])
+	return topics
+}
+
+// CreatePulsarConsumer subscribes to the topics, every routed topic when
+// none are given. The key-shared subscription spreads subjects over the
+// connected consumers while keeping the events of a subject in order.
+func (c *PulsarConfig) CreatePulsarConsumer(ctx context.Context, client pulsar.Client, topics ...string) (pulsar.Consumer, error) {
+	if len(topics) == 0 {
+		topics = c.Topics()
+	}
+
 	consumerOptions := pulsar.ConsumerOptions{
-		Topic:                       consumerTopic,
-		SubscriptionName:            "my-subscription",
+		Topics:                      topics,
+		SubscriptionName:            c.SubscriptionName,
+		Type:                        pulsar.KeyShared,
 		SubscriptionInitialPosition: pulsar.SubscriptionPositionEarliest,
 	}
 
@@ -76,7 +130,7 @@ func (c *PulsarConfig) CreatePulsarConsumer(ctx context.Context, client pulsar.C
 		return nil, fmt.Errorf("failed to create Pulsar consumer: %w", err)
 	}
 
-	slog.Info("Pulsar consumer created successfully", "topic", c.TopicName)
+	slog.Info("Pulsar consumer created successfully", "topics", topics, "subscription", c.SubscriptionName)
 
 	return consumer, nil
 }
@@ -0,0 +1,73 @@
+package queue
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/apache/pulsar-client-go/pulsaradmin"
+	"github.com/apache/pulsar-client-go/pulsaradmin/pkg/utils"
+)
+
+// schemaTypeNames maps client schema types to the names used by the admin API.
+var schemaTypeNames = map[pulsar.SchemaType]string{
+	pulsar.JSON:        "JSON",
+	pulsar.PROTOBUF:    "PROTOBUF",
+	pulsar.AVRO:        "AVRO",
+	pulsar.ProtoNative: "PROTOBUF_NATIVE",
+}
+
+// CheckSchemaCompatibility asks the broker whether the producer schema can be
+// registered on every routed topic under its compatibility strategy. The check
+// is skipped for schemaless producers.
+func (c *PulsarConfig) CheckSchemaCompatibility() error {
+	if c.Schema == nil {
+		return nil
+	}
+	if c.AdminURL == "" {
+		return errors.New("pulsar admin url is required to check the producer schema")
+	}
+
+	info := c.Schema.GetSchemaInfo()
+	typeName, ok := schemaTypeNames[info.Type]
+	if !ok {
+		return fmt.Errorf("unsupported schema type %d", info.Type)
+	}
+
+	token, err := c.Token()
+	if err != nil {
+		return fmt.Errorf("failed to get Pulsar token: %w", err)
+	}
+
+	admin, err := pulsaradmin.NewClient(&pulsaradmin.Config{
+		WebServiceURL: c.AdminURL,
+		Token:         token,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create Pulsar admin client: %w", err)
+	}
+
+	schemaInfo := utils.SchemaInfo{
+		Name:       info.Name,
+		Schema:     []byte(info.Schema),
+		Type:       typeName,
+		Properties: info.Properties,
+	}
+
+	for _, topic := range c.Topics() {
+		result, err := admin.Schemas().TestCompatibilityWithSchemaInfo(topic, schemaInfo)
+		if err != nil {
+			return fmt.Errorf("failed to check schema compatibility of topic %s: %w", topic, err)
+		}
+		if !result.IsCompatibility {
+			return fmt.Errorf("%s schema is incompatible with topic %s under the %s strategy",
+				typeName, topic, result.SchemaCompatibilityStrategy)
+		}
+
+		slog.Info("Pulsar schema is compatible", "topic", topic, "type", typeName,
+			"strategy", result.SchemaCompatibilityStrategy)
+	}
+
+	return nil
+}
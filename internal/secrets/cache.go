@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Cache serves secrets from memory, fetching them from its provider on first
+// use or once they expired, and refreshes them in the background so rotated
+// values reach clients on their next authentication.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+	interval time.Duration
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// NewCache initializes and returns a Cache in front of the provider. A zero
+// ttl keeps values until the background refresh replaces them.
+func NewCache(provider Provider, ttl, interval time.Duration) *Cache {
+	return &Cache{
+		provider: provider,
+		ttl:      ttl,
+		interval: interval,
+		now:      time.Now,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+func (c *Cache) Name() string {
+	return "cached " + c.provider.Name()
+}
+
+// Get returns the cached value, fetching it when missing or expired. An
+// expired value is still served when the provider fails.
+func (c *Cache) Get(ctx context.Context, name string) (string, error) {
+	c.mu.RLock()
+	entry, ok := c.entries[name]
+	c.mu.RUnlock()
+
+	if ok && (c.ttl <= 0 || c.now().Sub(entry.fetchedAt) < c.ttl) {
+		return entry.value, nil
+	}
+
+	value, err := c.fetch(ctx, name)
+	if err != nil {
+		if ok {
+			slog.Warn("failed to refresh secret, serving the cached value", "secret", name, "error", err)
+			return entry.value, nil
+		}
+		return "", err
+	}
+	return value, nil
+}
+
+// Supplier returns a function returning the current value of the secret,
+// for clients that look their credentials up on every authentication.
+func (c *Cache) Supplier(name string) func() (string, error) {
+	return func() (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), supplierTimeout)
+		defer cancel()
+
+		return c.Get(ctx, name)
+	}
+}
+
+// Run refreshes every cached secret on the rotation interval until ctx is done.
+func (c *Cache) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.refresh(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (c *Cache) refresh(ctx context.Context) {
+	c.mu.RLock()
+	names := make([]string, 0, len(c.entries))
+	for name := range c.entries {
+		names = append(names, name)
+	}
+	c.mu.RUnlock()
+
+	for _, name := range names {
+		if _, err := c.fetch(ctx, name); err != nil {
+			slog.Warn("failed to refresh secret", "secret", name, "error", err)
+		}
+	}
+}
+
+func (c *Cache) fetch(ctx context.Context, name string) (string, error) {
+	value, err := c.provider.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if previous, ok := c.entries[name]; ok && previous.value != value {
+		slog.Info("secret rotated", "secret", name)
+	}
+	c.entries[name] = cacheEntry{value: value, fetchedAt: c.now()}
+	return value, nil
+}
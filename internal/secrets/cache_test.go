@@ -0,0 +1,93 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCacheGet(t *testing.T) {
+	const ttl = time.Minute
+
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		// after the first lookup the clock moves by elapsed, the secret is
+		// rotated to rotated and the provider fails with err
+		elapsed time.Duration
+		rotated string
+		err     error
+
+		want     string
+		wantGets int
+	}{
+		{name: "served from memory", ttl: ttl, elapsed: ttl / 2, rotated: "v2", want: "v1", wantGets: 1},
+		{name: "fetched again once expired", ttl: ttl, elapsed: ttl, rotated: "v2", want: "v2", wantGets: 2},
+		{name: "kept until rotated without a ttl", elapsed: 24 * time.Hour, rotated: "v2", want: "v1", wantGets: 1},
+		{name: "expired value served on errors", ttl: ttl, elapsed: ttl, err: errors.New("unavailable"), want: "v1", wantGets: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &mapProvider{name: "ssm", values: map[string]string{AstraToken: "v1"}}
+			cache := NewCache(provider, tt.ttl, time.Minute)
+			now := time.Date(2026, time.October, 19, 12, 0, 0, 0, time.UTC)
+			cache.now = func() time.Time { return now }
+
+			if got, err := cache.Get(context.Background(), AstraToken); err != nil || got != "v1" {
+				t.Fatalf("first Get() = %q, %v, want v1", got, err)
+			}
+
+			now = now.Add(tt.elapsed)
+			if tt.rotated != "" {
+				provider.values[AstraToken] = tt.rotated
+			}
+			provider.err = tt.err
+
+			got, err := cache.Get(context.Background(), AstraToken)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Get() = %q, want %q", got, tt.want)
+			}
+			if provider.gets != tt.wantGets {
+				t.Errorf("provider asked %d times, want %d", provider.gets, tt.wantGets)
+			}
+		})
+	}
+}
+
+func TestCacheGetMissing(t *testing.T) {
+	cache := NewCache(&mapProvider{name: "environment"}, 0, time.Minute)
+
+	if _, err := cache.Get(context.Background(), AstraToken); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestCacheRefreshRotates(t *testing.T) {
+	provider := &mapProvider{name: "ssm", values: map[string]string{AstraToken: "v1"}}
+	cache := NewCache(provider, 0, time.Minute)
+	supplier := cache.Supplier(AstraToken)
+
+	if got, err := supplier(); err != nil || got != "v1" {
+		t.Fatalf("supplier() = %q, %v, want v1", got, err)
+	}
+
+	provider.values[AstraToken] = "v2"
+	cache.refresh(context.Background())
+
+	if got, err := supplier(); err != nil || got != "v2" {
+		t.Errorf("supplier() after rotation = %q, %v, want v2", got, err)
+	}
+
+	// a failed refresh keeps the value in place
+	provider.err = errors.New("unavailable")
+	cache.refresh(context.Background())
+
+	if got, err := supplier(); err != nil || got != "v2" {
+		t.Errorf("supplier() after a failed refresh = %q, %v, want v2", got, err)
+	}
+}
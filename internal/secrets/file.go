@@ -0,0 +1,130 @@
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileProvider reads secrets mounted as files named after them, such as
+// Docker or Kubernetes secrets under /run/secrets. Files are read on every
+// lookup so a remounted secret is picked up.
+type FileProvider struct {
+	Dir string
+}
+
+func (p FileProvider) Name() string {
+	return "files " + p.Dir
+}
+
+func (p FileProvider) Get(_ context.Context, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(p.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// EncryptedFileProvider reads secrets from a local file holding a YAML map
+// of names to values sealed with AES-256-GCM, for laptops without a secret
+// store. Create the file with Seal.
+type EncryptedFileProvider struct {
+	path string
+	aead cipher.AEAD
+}
+
+// NewEncryptedFileProvider initializes and returns an EncryptedFileProvider
+// for the file, decrypting with the base64 encoded 32-byte key.
+func NewEncryptedFileProvider(path, key string) (*EncryptedFileProvider, error) {
+	if path == "" {
+		return nil, errors.New("the encrypted secret provider requires a file")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedFileProvider{path: path, aead: aead}, nil
+}
+
+func (p *EncryptedFileProvider) Name() string {
+	return "encrypted file " + p.path
+}
+
+// Get decrypts the file on every lookup so an updated file is picked up.
+func (p *EncryptedFileProvider) Get(_ context.Context, name string) (string, error) {
+	sealed, err := os.ReadFile(p.path)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := p.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("encrypted secret file is truncated")
+	}
+	plaintext, err := p.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret file: %w", err)
+	}
+
+	var values map[string]string
+	if err := yaml.Unmarshal(plaintext, &values); err != nil {
+		return "", fmt.Errorf("failed to parse secret file: %w", err)
+	}
+
+	value, ok := values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+// Seal encrypts a YAML map of secret names to values with the base64
+// encoded 32-byte key, in the format EncryptedFileProvider reads.
+func Seal(key string, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]string
+	if err := yaml.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("secrets must be a YAML map of names to values: %w", err)
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func newAEAD(key string) (cipher.AEAD, error) {
+	if key == "" {
+		return nil, fmt.Errorf("the secret file key is not set, export it in %s", KeyEnv)
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret file key: %w", err)
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("secret file key must be 32 bytes, got %d", len(raw))
+	}
+
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
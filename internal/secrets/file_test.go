@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func newKey(t *testing.T) string {
+	t.Helper()
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestFileProviderGet(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, AstraToken), []byte("AstraCS:token\n"), 0o600); err != nil {
+		t.Fatalf("failed to write secret: %v", err)
+	}
+	provider := FileProvider{Dir: dir}
+
+	if got, err := provider.Get(context.Background(), AstraToken); err != nil || got != "AstraCS:token" {
+		t.Errorf("Get() = %q, %v, want the trimmed file content", got, err)
+	}
+	if _, err := provider.Get(context.Background(), CassandraPassword); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() of a missing file error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestEncryptedFileProviderRoundTrip(t *testing.T) {
+	key := newKey(t)
+	sealed, err := Seal(key, []byte("ASTRA_TOKEN: AstraCS:token\nPULSAR_TOKEN: pulsar\n"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "secrets.enc")
+	if err := os.WriteFile(path, sealed, 0o600); err != nil {
+		t.Fatalf("failed to write sealed file: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		key     string
+		secret  string
+		want    string
+		wantErr bool
+		// wantNotFound lets the chain try the next provider
+		wantNotFound bool
+	}{
+		{name: "sealed secret", key: key, secret: "ASTRA_TOKEN", want: "AstraCS:token"},
+		{name: "other sealed secret", key: key, secret: "PULSAR_TOKEN", want: "pulsar"},
+		{name: "missing secret", key: key, secret: "CASSANDRA_PASSWORD", wantErr: true, wantNotFound: true},
+		{name: "wrong key", key: newKey(t), secret: "ASTRA_TOKEN", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewEncryptedFileProvider(path, tt.key)
+			if err != nil {
+				t.Fatalf("NewEncryptedFileProvider() error = %v", err)
+			}
+
+			got, err := provider.Get(context.Background(), tt.secret)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrNotFound) != tt.wantNotFound {
+				t.Errorf("Get() error = %v, want ErrNotFound %v", err, tt.wantNotFound)
+			}
+			if got != tt.want {
+				t.Errorf("Get() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSealRejects(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		plaintext string
+	}{
+		{name: "missing key", plaintext: "ASTRA_TOKEN: token"},
+		{name: "short key", key: base64.StdEncoding.EncodeToString([]byte("short")), plaintext: "ASTRA_TOKEN: token"},
+		{name: "key not base64", key: "not base64!", plaintext: "ASTRA_TOKEN: token"},
+		{name: "not a map", key: newKey(t), plaintext: "- ASTRA_TOKEN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Seal(tt.key, []byte(tt.plaintext)); err == nil {
+				t.Error("Seal() error = nil, want an error")
+			}
+		})
+	}
+}
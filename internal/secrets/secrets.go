@@ -0,0 +1,143 @@
+// Package secrets resolves the credentials the service connects with from
+// pluggable backends and hands them to the components that need them,
+// without going through the process environment.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Secret names.
+const (
+	AstraToken        = "ASTRA_TOKEN"
+	CassandraPassword = "CASSANDRA_PASSWORD"
+	PulsarToken       = "PULSAR_TOKEN"
+)
+
+// Provider kinds, as listed in the config.
+const (
+	KindEnv       = "env"
+	KindFile      = "file"
+	KindSSM       = "ssm"
+	KindEncrypted = "encrypted"
+)
+
+// KeyEnv names the environment variable holding the base64 encoded
+// AES-256 key of the encrypted secrets file.
+const KeyEnv = "PRODUCTS_SECRETS_KEY"
+
+const (
+	DefaultSSMPrefix        = "/myapp"
+	DefaultRotationInterval = 5 * time.Minute
+
+	// supplierTimeout bounds a secret lookup made while a client authenticates.
+	supplierTimeout = 10 * time.Second
+)
+
+// ErrNotFound is returned by providers that do not hold the secret.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider looks up secrets by name.
+type Provider interface {
+	Name() string
+	Get(ctx context.Context, name string) (string, error)
+}
+
+// Config holds the configuration for the secret providers.
+type Config struct {
+	Providers     []string // tried in order, defaults to env
+	SSMPrefix     string   // parameter path the ssm provider reads names under
+	Dir           string   // directory of mounted secret files
+	EncryptedFile string   // file sealed with the key in KeyEnv
+
+	CacheTTL         time.Duration // how long a value is served before it is fetched again, zero keeps it until rotated
+	RotationInterval time.Duration // how often cached values are refreshed in the background
+}
+
+// New builds the providers listed in the config, chained in order and
+// cached.
+func New(ctx context.Context, cfg *Config) (*Cache, error) {
+	kinds := cfg.Providers
+	if len(kinds) == 0 {
+		kinds = []string{KindEnv}
+	}
+
+	var chain Chain
+	for _, kind := range kinds {
+		var (
+			provider Provider
+			err      error
+		)
+		switch kind {
+		case KindEnv:
+			provider = EnvProvider{}
+		case KindFile:
+			provider = FileProvider{Dir: cfg.Dir}
+		case KindSSM:
+			prefix := cfg.SSMPrefix
+			if prefix == "" {
+				prefix = DefaultSSMPrefix
+			}
+			provider, err = NewSSMProvider(ctx, prefix)
+		case KindEncrypted:
+			provider, err = NewEncryptedFileProvider(cfg.EncryptedFile, os.Getenv(KeyEnv))
+		default:
+			err = fmt.Errorf("unknown secret provider %q", kind)
+		}
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, provider)
+	}
+
+	interval := cfg.RotationInterval
+	if interval <= 0 {
+		interval = DefaultRotationInterval
+	}
+	return NewCache(chain, cfg.CacheTTL, interval), nil
+}
+
+// Chain tries its providers in order and returns the first value found.
+type Chain []Provider
+
+func (c Chain) Name() string {
+	names := make([]string, len(c))
+	for i, provider := range c {
+		names[i] = provider.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+func (c Chain) Get(ctx context.Context, name string) (string, error) {
+	for _, provider := range c {
+		value, err := provider.Get(ctx, name)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get secret %s from %s: %w", name, provider.Name(), err)
+		}
+		return value, nil
+	}
+	return "", fmt.Errorf("%w: %s in %s", ErrNotFound, name, c.Name())
+}
+
+// EnvProvider reads secrets from environment variables of the same name.
+type EnvProvider struct{}
+
+func (EnvProvider) Name() string {
+	return "environment"
+}
+
+func (EnvProvider) Get(_ context.Context, name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
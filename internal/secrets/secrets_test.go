@@ -0,0 +1,123 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// mapProvider serves the secrets in values and fails every lookup while err
+// is set.
+type mapProvider struct {
+	name   string
+	values map[string]string
+	err    error
+	gets   int
+}
+
+func (p *mapProvider) Name() string {
+	return p.name
+}
+
+func (p *mapProvider) Get(_ context.Context, name string) (string, error) {
+	p.gets++
+	if p.err != nil {
+		return "", p.err
+	}
+	value, ok := p.values[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *Config
+		wantName string
+		wantErr  bool
+	}{
+		{name: "defaults to env", cfg: &Config{}, wantName: "cached environment"},
+		{name: "in the configured order", cfg: &Config{Providers: []string{KindFile, KindEnv}, Dir: "/run/secrets"}, wantName: "cached files /run/secrets, environment"},
+		{name: "encrypted without a key", cfg: &Config{Providers: []string{KindEncrypted}, EncryptedFile: "secrets.enc"}, wantErr: true},
+		{name: "unknown provider", cfg: &Config{Providers: []string{"vault"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(KeyEnv, "")
+
+			cache, err := New(context.Background(), tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cache.Name() != tt.wantName {
+				t.Errorf("Name() = %q, want %q", cache.Name(), tt.wantName)
+			}
+		})
+	}
+}
+
+func TestChainGet(t *testing.T) {
+	storeErr := errors.New("parameter store unavailable")
+
+	tests := []struct {
+		name      string
+		providers []*mapProvider
+		secret    string
+		want      string
+		wantErr   error // matched with errors.Is, nil when no error is expected
+	}{
+		{
+			name: "first provider holding the secret wins",
+			providers: []*mapProvider{
+				{name: "environment", values: map[string]string{"ASTRA_TOKEN": "from env"}},
+				{name: "ssm", values: map[string]string{"ASTRA_TOKEN": "from ssm"}},
+			},
+			secret: "ASTRA_TOKEN",
+			want:   "from env",
+		},
+		{
+			name: "missing secrets fall through",
+			providers: []*mapProvider{
+				{name: "environment"},
+				{name: "ssm", values: map[string]string{"ASTRA_TOKEN": "from ssm"}},
+			},
+			secret: "ASTRA_TOKEN",
+			want:   "from ssm",
+		},
+		{
+			name: "provider errors stop the chain",
+			providers: []*mapProvider{
+				{name: "ssm", err: storeErr},
+				{name: "environment", values: map[string]string{"ASTRA_TOKEN": "from env"}},
+			},
+			secret:  "ASTRA_TOKEN",
+			wantErr: storeErr,
+		},
+		{
+			name:      "not found anywhere",
+			providers: []*mapProvider{{name: "environment"}, {name: "ssm"}},
+			secret:    "ASTRA_TOKEN",
+			wantErr:   ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chain Chain
+			for _, provider := range tt.providers {
+				chain = append(chain, provider)
+			}
+
+			got, err := chain.Get(context.Background(), tt.secret)
+			if !errors.Is(err, tt.wantErr) || (err != nil) != (tt.wantErr != nil) {
+				t.Fatalf("Get() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Get() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
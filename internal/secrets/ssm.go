@@ -0,0 +1,50 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// SSMProvider reads secrets from SSM Parameter Store, each stored as a
+// SecureString named after the secret under the prefix, e.g.
+// /myapp/PULSAR_TOKEN.
+type SSMProvider struct {
+	client *ssm.Client
+	prefix string
+}
+
+// NewSSMProvider initializes and returns an SSMProvider using the default
+// AWS credential chain.
+func NewSSMProvider(ctx context.Context, prefix string) (*SSMProvider, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return &SSMProvider{client: ssm.NewFromConfig(cfg), prefix: prefix}, nil
+}
+
+func (p *SSMProvider) Name() string {
+	return "ssm " + p.prefix
+}
+
+func (p *SSMProvider) Get(ctx context.Context, name string) (string, error) {
+	param, err := p.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(path.Join(p.prefix, name)),
+		WithDecryption: aws.Bool(true),
+	})
+	var notFound *types.ParameterNotFound
+	if errors.As(err, &notFound) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(param.Parameter.Value), nil
+}
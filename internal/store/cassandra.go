@@ -0,0 +1,658 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/internal/database"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	insertCategoryQuery = `INSERT INTO chat.categories(id, name, description, created_at, event_sequence) VALUES(?, ?, ?, ?, ?)`
+
+	insertProductQuery = `INSERT INTO chat.products
+		(id, name, description, price, stock, category_id, created_at, updated_at, event_sequence)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	getCategorySnapshotQuery = `SELECT id, name, description, created_at, event_sequence FROM chat.categories WHERE id = ?`
+
+	scanCategorySnapshotsQuery = `SELECT id, name, description, created_at, event_sequence FROM chat.categories`
+
+	getProductSnapshotQuery = `SELECT id, name, description, price, stock, category_id, created_at, updated_at, event_sequence
+		FROM chat.products
+		WHERE category_id = ? AND id = ?`
+
+	scanProductSnapshotsQuery = `SELECT id, name, description, price, stock, category_id, created_at, updated_at, event_sequence
+		FROM chat.products`
+
+	insertOutboxQuery = `INSERT INTO chat.products_outbox
+		(id, bucket, payload, data, event_type, trace_context)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	listOutboxQuery = `
+		SELECT id, bucket, payload, data, event_type, publish_sequence, attempts, last_error, next_attempt_at, trace_context
+		FROM chat.products_outbox
+		WHERE bucket = ?
+		ORDER BY id ASC;
+	`
+
+	deleteOutboxQuery = `DELETE FROM chat.products_outbox WHERE bucket = ? AND id = ?`
+
+	assignPublishSequenceQuery = `UPDATE chat.products_outbox SET publish_sequence = ? WHERE bucket = ? AND id = ?`
+
+	saveReservedSequenceQuery = `INSERT INTO chat.products_outbox_sequences (topic, reserved) VALUES (?, ?)`
+
+	getReservedSequenceQuery = `SELECT reserved FROM chat.products_outbox_sequences WHERE topic = ?`
+
+	recordOutboxFailureQuery = `UPDATE chat.products_outbox
+		SET attempts = ?, last_error = ?, next_attempt_at = ?, publish_sequence = ?
+		WHERE bucket = ? AND id = ?`
+
+	requeueOutboxQuery = `INSERT INTO chat.products_outbox
+		(id, bucket, payload, data, event_type, trace_context, attempts)
+		VALUES (?, ?, ?, ?, ?, ?, 0)`
+
+	insertDeadLetterQuery = `INSERT INTO chat.products_outbox_dlq
+		(id, bucket, payload, data, event_type, attempts, last_error, dead_lettered_at, trace_context)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	listDeadLettersQuery = `SELECT id, bucket, payload, data, event_type, attempts, last_error, dead_lettered_at, trace_context
+		FROM chat.products_outbox_dlq`
+
+	getDeadLetterQuery = `SELECT id, bucket, payload, data, event_type, attempts, last_error, dead_lettered_at, trace_context
+		FROM chat.products_outbox_dlq
+		WHERE id = ?`
+
+	deleteDeadLetterQuery = `DELETE FROM chat.products_outbox_dlq WHERE id = ?`
+
+	getOutboxCheckpointQuery = `SELECT bucket FROM chat.products_outbox_checkpoint WHERE relay = ?`
+
+	saveOutboxCheckpointQuery = `INSERT INTO chat.products_outbox_checkpoint (relay, bucket) VALUES (?, ?)`
+
+	claimOutboxShardsQuery = `INSERT INTO chat.products_outbox_windows (time_window, shards) VALUES (?, ?) IF NOT EXISTS`
+
+	getOutboxShardsQuery = `SELECT shards FROM chat.products_outbox_windows WHERE time_window = ?`
+
+	acquireLeaseQuery = `INSERT INTO chat.relay_leases (name, holder) VALUES (?, ?) IF NOT EXISTS USING TTL ?`
+
+	renewLeaseQuery = `UPDATE chat.relay_leases USING TTL ? SET holder = ? WHERE name = ? IF holder = ?`
+
+	releaseLeaseQuery = `DELETE FROM chat.relay_leases WHERE name = ? IF holder = ?`
+
+	getProjectionEventQuery = `SELECT event_id FROM chat.products_projection_events WHERE event_id = ?`
+
+	insertProjectionEventQuery = `INSERT INTO chat.products_projection_events (event_id, applied_at) VALUES (?, ?)`
+
+	getProductViewQuery = `SELECT id, name, description, price, stock, category_id, created_at, updated_at, event_sequence
+		FROM chat.products_by_id
+		WHERE id = ?`
+
+	insertProductViewQuery = `INSERT INTO chat.products_by_id
+		(id, name, description, price, stock, category_id, created_at, updated_at, event_sequence)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	deleteProductViewQuery = `DELETE FROM chat.products_by_id WHERE id = ?`
+
+	insertProductByPriceQuery = `INSERT INTO chat.products_by_price
+		(category_id, price, id, name, description, stock)
+		VALUES (?, ?, ?, ?, ?, ?)`
+
+	deleteProductByPriceQuery = `DELETE FROM chat.products_by_price WHERE category_id = ? AND price = ? AND id = ?`
+
+	insertCategoryProductQuery = `INSERT INTO chat.category_products (category_id, product_id) VALUES (?, ?)`
+
+	deleteCategoryProductQuery = `DELETE FROM chat.category_products WHERE category_id = ? AND product_id = ?`
+
+	countCategoryProductsQuery = `SELECT COUNT(*) FROM chat.category_products WHERE category_id = ?`
+
+	deleteCategoryProductsQuery = `DELETE FROM chat.category_products WHERE category_id = ?`
+
+	// projectionTables are truncated when the projection is rebuilt.
+	projectionTables = []string{
+		"chat.products_by_id",
+		"chat.products_by_price",
+		"chat.category_products",
+		"chat.products_projection_events",
+	}
+)
+
+// outboxRelayName is the checkpoint row owned by the products outbox relay.
+const outboxRelayName = "products"
+
+var _ Store = (*CassandraStore)(nil)
+
+// CassandraStore implements Store on top of a gocql session.
+type CassandraStore struct {
+	session  *gocql.Session
+	profiles database.QueryProfiles
+}
+
+// NewCassandraStore returns a Store backed by the given session, tuning each
+// query with the profile of its type. Nil profiles keep the session defaults.
+func NewCassandraStore(session *gocql.Session, profiles database.QueryProfiles) *CassandraStore {
+	return &CassandraStore{session: session, profiles: profiles}
+}
+
+func (s *CassandraStore) query(queryType database.QueryType, stmt string, values ...any) *gocql.Query {
+	return s.profiles.Query(s.session.Query(stmt, values...), queryType)
+}
+
+func (s *CassandraStore) batch(ctx context.Context) *gocql.Batch {
+	batch := s.session.NewBatch(gocql.LoggedBatch)
+	batch.WithContext(ctx)
+	return s.profiles.Batch(batch)
+}
+
+// CreateCategory writes the category and its outbox event in a single logged batch.
+func (s *CassandraStore) CreateCategory(ctx context.Context, category *pb.Category, event OutboxEvent) error {
+	batch := s.batch(ctx)
+
+	batch.Query(
+		insertCategoryQuery,
+		category.Id,
+		category.Name,
+		category.Description,
+		category.CreatedAt.AsTime(),
+		int64(event.Sequence),
+	)
+	batch.Query(
+		insertOutboxQuery,
+		event.Id, event.Bucket, event.Payload, event.Data, event.EventType, event.TraceContext,
+	)
+
+	return s.session.ExecuteBatch(batch)
+}
+
+// CreateProduct writes the product and its outbox event in a single logged batch.
+func (s *CassandraStore) CreateProduct(ctx context.Context, product *pb.Product, event OutboxEvent) error {
+	batch := s.batch(ctx)
+
+	batch.Query(
+		insertProductQuery,
+		product.Id, product.Name, product.Description, product.Price,
+		product.Stock, product.CategoryId, product.CreatedAt.AsTime(), product.UpdatedAt.AsTime(),
+		int64(event.Sequence),
+	)
+
+	// Add outbox event query
+	batch.Query(
+		insertOutboxQuery,
+		event.Id, event.Bucket, event.Payload, event.Data, event.EventType, event.TraceContext,
+	)
+
+	return s.session.ExecuteBatch(batch)
+}
+
+func (s *CassandraStore) GetCategorySnapshot(ctx context.Context, id int64) (*Snapshot, error) {
+	var (
+		category  pb.Category
+		createdAt time.Time
+		sequence  int64
+	)
+
+	err := s.query(database.QueryRead, getCategorySnapshotQuery, id).WithContext(ctx).
+		Scan(&category.Id, &category.Name, &category.Description, &createdAt, &sequence)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	category.CreatedAt = timestamppb.New(createdAt)
+	return &Snapshot{Category: &category, Sequence: uint64(sequence)}, nil
+}
+
+func (s *CassandraStore) ScanCategorySnapshots(ctx context.Context, fn func(Snapshot) error) error {
+	iter := s.query(database.QueryScan, scanCategorySnapshotsQuery).WithContext(ctx).Iter()
+
+	var (
+		category  pb.Category
+		createdAt time.Time
+		sequence  int64
+	)
+	for iter.Scan(&category.Id, &category.Name, &category.Description, &createdAt, &sequence) {
+		snapshot := Snapshot{
+			Category: &pb.Category{
+				Id:          category.Id,
+				Name:        category.Name,
+				Description: category.Description,
+				CreatedAt:   timestamppb.New(createdAt),
+			},
+			Sequence: uint64(sequence),
+		}
+		if err := fn(snapshot); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("failed to scan categories: %w", err)
+	}
+	return nil
+}
+
+func (s *CassandraStore) GetProductSnapshot(ctx context.Context, categoryID, id int64) (*Snapshot, error) {
+	var (
+		product              pb.Product
+		createdAt, updatedAt time.Time
+		sequence             int64
+	)
+
+	err := s.query(database.QueryRead, getProductSnapshotQuery, categoryID, id).WithContext(ctx).Scan(
+		&product.Id, &product.Name, &product.Description, &product.Price,
+		&product.Stock, &product.CategoryId, &createdAt, &updatedAt, &sequence,
+	)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	product.CreatedAt = timestamppb.New(createdAt)
+	product.UpdatedAt = timestamppb.New(updatedAt)
+	return &Snapshot{Product: &product, Sequence: uint64(sequence)}, nil
+}
+
+func (s *CassandraStore) ScanProductSnapshots(ctx context.Context, categoryID int64, fn func(Snapshot) error) error {
+	query := s.query(database.QueryScan, scanProductSnapshotsQuery)
+	if categoryID != 0 {
+		query = s.query(database.QueryScan, scanProductSnapshotsQuery+" WHERE category_id = ?", categoryID)
+	}
+	iter := query.WithContext(ctx).Iter()
+
+	var (
+		product              pb.Product
+		createdAt, updatedAt time.Time
+		sequence             int64
+	)
+	for iter.Scan(
+		&product.Id, &product.Name, &product.Description, &product.Price,
+		&product.Stock, &product.CategoryId, &createdAt, &updatedAt, &sequence,
+	) {
+		snapshot := Snapshot{
+			Product: &pb.Product{
+				Id:          product.Id,
+				Name:        product.Name,
+				Description: product.Description,
+				Price:       product.Price,
+				Stock:       product.Stock,
+				CategoryId:  product.CategoryId,
+				CreatedAt:   timestamppb.New(createdAt),
+				UpdatedAt:   timestamppb.New(updatedAt),
+			},
+			Sequence: uint64(sequence),
+		}
+		if err := fn(snapshot); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return fmt.Errorf("failed to scan products: %w", err)
+	}
+	return nil
+}
+
+func (s *CassandraStore) InsertOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	return s.query(database.QueryWrite,
+		insertOutboxQuery,
+		event.Id, event.Bucket, event.Payload, event.Data, event.EventType, event.TraceContext,
+	).WithContext(ctx).Exec()
+}
+
+func (s *CassandraStore) ListOutboxEvents(ctx context.Context, bucket string) ([]OutboxEvent, error) {
+	var events []OutboxEvent
+
+	iter := s.query(database.QueryScan, listOutboxQuery, bucket).WithContext(ctx).Iter()
+
+	var event OutboxEvent
+	for iter.Scan(
+		&event.Id, &event.Bucket, &event.Payload, &event.Data, &event.EventType,
+		&event.PublishSequence, &event.Attempts, &event.LastError, &event.NextAttemptAt, &event.TraceContext,
+	) {
+		events = append(events, event)
+		// gocql decodes blobs into the slice it is given, start the next row
+		// afresh so it does not overwrite the Data of this one
+		event = OutboxEvent{}
+	}
+
+	// Handle any iteration errors
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (s *CassandraStore) DeleteOutboxEvent(ctx context.Context, bucket string, id gocql.UUID) error {
+	return s.query(database.QueryWrite, deleteOutboxQuery, bucket, id).WithContext(ctx).Exec()
+}
+
+// AssignPublishSequence stores the id on the event and raises the topic's
+// reservation mark in a single logged batch. Only the relay leader reserves
+// ids and they only grow, so the mark is written without a condition.
+func (s *CassandraStore) AssignPublishSequence(ctx context.Context, event OutboxEvent, topic string) error {
+	batch := s.batch(ctx)
+
+	batch.Query(assignPublishSequenceQuery, event.PublishSequence, event.Bucket, event.Id)
+	batch.Query(saveReservedSequenceQuery, topic, event.PublishSequence)
+
+	return s.session.ExecuteBatch(batch)
+}
+
+func (s *CassandraStore) GetReservedSequence(ctx context.Context, topic string) (int64, error) {
+	var reserved int64
+
+	err := s.query(database.QueryRead, getReservedSequenceQuery, topic).WithContext(ctx).Scan(&reserved)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get reserved sequence: %w", err)
+	}
+	return reserved, nil
+}
+
+func (s *CassandraStore) RecordOutboxFailure(ctx context.Context, event OutboxEvent) error {
+	return s.query(database.QueryWrite,
+		recordOutboxFailureQuery,
+		event.Attempts, event.LastError, event.NextAttemptAt, event.PublishSequence,
+		event.Bucket, event.Id,
+	).WithContext(ctx).Exec()
+}
+
+// DeadLetterOutboxEvent copies the event into the dead-letter table and
+// removes it from the outbox in a single logged batch.
+func (s *CassandraStore) DeadLetterOutboxEvent(ctx context.Context, event OutboxEvent) error {
+	batch := s.batch(ctx)
+
+	batch.Query(
+		insertDeadLetterQuery,
+		event.Id, event.Bucket, event.Payload, event.Data, event.EventType,
+		event.Attempts, event.LastError, time.Now(), event.TraceContext,
+	)
+	batch.Query(deleteOutboxQuery, event.Bucket, event.Id)
+
+	return s.session.ExecuteBatch(batch)
+}
+
+func (s *CassandraStore) ListDeadLetters(ctx context.Context, pageSize int, pageState []byte) ([]DeadLetter, []byte, error) {
+	var deadLetters []DeadLetter
+
+	iter := s.query(database.QueryScan, listDeadLettersQuery).WithContext(ctx).
+		PageSize(pageSize).PageState(pageState).Iter()
+
+	nextPageState := iter.PageState()
+
+	var deadLetter DeadLetter
+	for iter.Scan(
+		&deadLetter.Id, &deadLetter.Bucket, &deadLetter.Payload, &deadLetter.Data, &deadLetter.EventType,
+		&deadLetter.Attempts, &deadLetter.LastError, &deadLetter.DeadLetteredAt, &deadLetter.TraceContext,
+	) {
+		deadLetters = append(deadLetters, deadLetter)
+		// Start afresh, the next row's blob would overwrite this one's Data
+		deadLetter = DeadLetter{}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	return deadLetters, nextPageState, nil
+}
+
+func (s *CassandraStore) GetDeadLetter(ctx context.Context, id gocql.UUID) (*DeadLetter, error) {
+	var deadLetter DeadLetter
+
+	err := s.query(database.QueryRead, getDeadLetterQuery, id).WithContext(ctx).Scan(
+		&deadLetter.Id, &deadLetter.Bucket, &deadLetter.Payload, &deadLetter.Data, &deadLetter.EventType,
+		&deadLetter.Attempts, &deadLetter.LastError, &deadLetter.DeadLetteredAt, &deadLetter.TraceContext,
+	)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter: %w", err)
+	}
+
+	return &deadLetter, nil
+}
+
+func (s *CassandraStore) RequeueDeadLetter(ctx context.Context, id gocql.UUID, bucket string) error {
+	deadLetter, err := s.GetDeadLetter(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	batch := s.batch(ctx)
+
+	batch.Query(
+		requeueOutboxQuery,
+		deadLetter.Id, bucket, deadLetter.Payload, deadLetter.Data, deadLetter.EventType, deadLetter.TraceContext,
+	)
+	batch.Query(deleteDeadLetterQuery, deadLetter.Id)
+
+	return s.session.ExecuteBatch(batch)
+}
+
+func (s *CassandraStore) DiscardDeadLetter(ctx context.Context, id gocql.UUID) error {
+	if _, err := s.GetDeadLetter(ctx, id); err != nil {
+		return err
+	}
+
+	return s.query(database.QueryWrite, deleteDeadLetterQuery, id).WithContext(ctx).Exec()
+}
+
+func (s *CassandraStore) GetOutboxCheckpoint(ctx context.Context) (string, error) {
+	var bucket string
+
+	err := s.query(database.QueryRead, getOutboxCheckpointQuery, outboxRelayName).WithContext(ctx).Scan(&bucket)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get outbox checkpoint: %w", err)
+	}
+
+	return bucket, nil
+}
+
+func (s *CassandraStore) SaveOutboxCheckpoint(ctx context.Context, checkpoint string) error {
+	return s.query(database.QueryWrite, saveOutboxCheckpointQuery, outboxRelayName, checkpoint).WithContext(ctx).Exec()
+}
+
+func (s *CassandraStore) ClaimOutboxShards(ctx context.Context, window string, shards int) (int, error) {
+	existing := map[string]interface{}{}
+
+	applied, err := s.query(database.QueryLWT, claimOutboxShardsQuery, window, shards).
+		WithContext(ctx).MapScanCAS(existing)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox shards: %w", err)
+	}
+	if applied {
+		return shards, nil
+	}
+
+	current, _ := existing["shards"].(int)
+	return current, nil
+}
+
+func (s *CassandraStore) GetOutboxShards(ctx context.Context, window string) (int, error) {
+	var shards int
+
+	err := s.query(database.QueryRead, getOutboxShardsQuery, window).WithContext(ctx).Scan(&shards)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get outbox shards: %w", err)
+	}
+
+	return shards, nil
+}
+
+func (s *CassandraStore) TryAcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (string, bool, error) {
+	existing := map[string]interface{}{}
+
+	applied, err := s.query(database.QueryLWT, acquireLeaseQuery, name, holder, ttlSeconds(ttl)).
+		WithContext(ctx).MapScanCAS(existing)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if applied {
+		return holder, true, nil
+	}
+
+	current, _ := existing["holder"].(string)
+	return current, false, nil
+}
+
+func (s *CassandraStore) RenewLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	var current string
+
+	applied, err := s.query(database.QueryLWT, renewLeaseQuery, ttlSeconds(ttl), holder, name, holder).
+		WithContext(ctx).ScanCAS(&current)
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lease: %w", err)
+	}
+
+	return applied, nil
+}
+
+func (s *CassandraStore) ReleaseLease(ctx context.Context, name, holder string) error {
+	var current string
+
+	if _, err := s.query(database.QueryLWT, releaseLeaseQuery, name, holder).WithContext(ctx).ScanCAS(&current); err != nil {
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+func (s *CassandraStore) ProjectionEventApplied(ctx context.Context, eventID string) (bool, error) {
+	var id string
+
+	err := s.query(database.QueryRead, getProjectionEventQuery, eventID).WithContext(ctx).Scan(&id)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get projection event: %w", err)
+	}
+	return true, nil
+}
+
+func (s *CassandraStore) GetProductView(ctx context.Context, id int64) (*ProductView, error) {
+	var (
+		product              pb.Product
+		createdAt, updatedAt time.Time
+		sequence             int64
+	)
+
+	err := s.query(database.QueryRead, getProductViewQuery, id).WithContext(ctx).Scan(
+		&product.Id, &product.Name, &product.Description, &product.Price,
+		&product.Stock, &product.CategoryId, &createdAt, &updatedAt, &sequence,
+	)
+	if errors.Is(err, gocql.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product view: %w", err)
+	}
+
+	product.CreatedAt = timestamppb.New(createdAt)
+	product.UpdatedAt = timestamppb.New(updatedAt)
+	return &ProductView{Product: &product, Sequence: uint64(sequence)}, nil
+}
+
+// PutProductView writes both product views, the category membership and the
+// applied event in a single logged batch, so the event is recorded only
+// along with its changes. Every write is an upsert, applying the event
+// again leaves the same rows.
+func (s *CassandraStore) PutProductView(ctx context.Context, view ProductView, previous *ProductView, eventID string) error {
+	product := view.Product
+
+	batch := s.batch(ctx)
+
+	if previous != nil && (previous.Product.CategoryId != product.CategoryId || previous.Product.Price != product.Price) {
+		batch.Query(
+			deleteProductByPriceQuery,
+			previous.Product.CategoryId, previous.Product.Price, previous.Product.Id,
+		)
+	}
+	if previous != nil && previous.Product.CategoryId != product.CategoryId {
+		batch.Query(deleteCategoryProductQuery, previous.Product.CategoryId, previous.Product.Id)
+	}
+	batch.Query(
+		insertProductViewQuery,
+		product.Id, product.Name, product.Description, product.Price,
+		product.Stock, product.CategoryId, product.CreatedAt.AsTime(), product.UpdatedAt.AsTime(),
+		int64(view.Sequence),
+	)
+	batch.Query(
+		insertProductByPriceQuery,
+		product.CategoryId, product.Price, product.Id, product.Name, product.Description, product.Stock,
+	)
+	batch.Query(insertCategoryProductQuery, product.CategoryId, product.Id)
+	batch.Query(insertProjectionEventQuery, eventID, time.Now())
+
+	return s.session.ExecuteBatch(batch)
+}
+
+// DeleteProductView removes both product views and the category membership
+// and records the applied event in a single logged batch.
+func (s *CassandraStore) DeleteProductView(ctx context.Context, view ProductView, eventID string) error {
+	batch := s.batch(ctx)
+
+	batch.Query(deleteProductViewQuery, view.Product.Id)
+	batch.Query(deleteProductByPriceQuery, view.Product.CategoryId, view.Product.Price, view.Product.Id)
+	batch.Query(deleteCategoryProductQuery, view.Product.CategoryId, view.Product.Id)
+	batch.Query(insertProjectionEventQuery, eventID, time.Now())
+
+	return s.session.ExecuteBatch(batch)
+}
+
+func (s *CassandraStore) CountCategoryProducts(ctx context.Context, categoryID int64) (int64, error) {
+	var count int64
+
+	if err := s.query(database.QueryRead, countCategoryProductsQuery, categoryID).WithContext(ctx).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count category products: %w", err)
+	}
+	return count, nil
+}
+
+// DeleteCategoryProducts clears the category's membership and records the
+// applied event in a single logged batch.
+func (s *CassandraStore) DeleteCategoryProducts(ctx context.Context, categoryID int64, eventID string) error {
+	batch := s.batch(ctx)
+
+	batch.Query(deleteCategoryProductsQuery, categoryID)
+	batch.Query(insertProjectionEventQuery, eventID, time.Now())
+
+	return s.session.ExecuteBatch(batch)
+}
+
+func (s *CassandraStore) ResetProjection(ctx context.Context) error {
+	for _, table := range projectionTables {
+		if err := s.query(database.QueryWrite, "TRUNCATE "+table).WithContext(ctx).Exec(); err != nil {
+			return fmt.Errorf("failed to truncate %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// ttlSeconds converts a lease duration to a CQL TTL, which must be at least one second.
+func ttlSeconds(ttl time.Duration) int {
+	if seconds := int(ttl / time.Second); seconds > 0 {
+		return seconds
+	}
+	return 1
+}
@@ -0,0 +1,477 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+	"google.golang.org/protobuf/proto"
+)
+
+var _ Store = (*MemoryStore)(nil)
+
+type lease struct {
+	holder  string
+	expires time.Time
+}
+
+type productKey struct {
+	categoryID int64
+	id         int64
+}
+
+// MemoryStore is an in-memory Store intended for tests and local development.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	categories map[int64]*pb.Category
+	products   map[productKey]*pb.Product
+
+	// last event sequence per category and product id
+	categorySequences map[int64]uint64
+	productSequences  map[int64]uint64
+
+	outbox     map[string]map[gocql.UUID]OutboxEvent
+	checkpoint string
+	windows    map[string]int
+	reserved   map[string]int64
+	leases     map[string]lease
+	dlq        map[gocql.UUID]DeadLetter
+
+	productViews     map[int64]ProductView
+	categoryProducts map[int64]map[int64]struct{}
+	appliedEvents    map[string]struct{}
+}
+
+// NewMemoryStore returns an empty in-memory Store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		categories: make(map[int64]*pb.Category),
+		products:   make(map[productKey]*pb.Product),
+
+		categorySequences: make(map[int64]uint64),
+		productSequences:  make(map[int64]uint64),
+
+		outbox:   make(map[string]map[gocql.UUID]OutboxEvent),
+		windows:  make(map[string]int),
+		reserved: make(map[string]int64),
+		leases:   make(map[string]lease),
+		dlq:      make(map[gocql.UUID]DeadLetter),
+
+		productViews:     make(map[int64]ProductView),
+		categoryProducts: make(map[int64]map[int64]struct{}),
+		appliedEvents:    make(map[string]struct{}),
+	}
+}
+
+func (s *MemoryStore) CreateCategory(_ context.Context, category *pb.Category, event OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.categories[category.Id] = proto.Clone(category).(*pb.Category)
+	s.categorySequences[category.Id] = event.Sequence
+	s.putOutboxEvent(event)
+	return nil
+}
+
+func (s *MemoryStore) CreateProduct(_ context.Context, product *pb.Product, event OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.products[productKey{product.CategoryId, product.Id}] = proto.Clone(product).(*pb.Product)
+	s.productSequences[product.Id] = event.Sequence
+	s.putOutboxEvent(event)
+	return nil
+}
+
+func (s *MemoryStore) GetCategorySnapshot(_ context.Context, id int64) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	category, ok := s.categories[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &Snapshot{
+		Category: proto.Clone(category).(*pb.Category),
+		Sequence: s.categorySequences[id],
+	}, nil
+}
+
+func (s *MemoryStore) ScanCategorySnapshots(ctx context.Context, fn func(Snapshot) error) error {
+	s.mu.RLock()
+	ids := make([]int64, 0, len(s.categories))
+	for id := range s.categories {
+		ids = append(ids, id)
+	}
+	s.mu.RUnlock()
+
+	// fn may write to the store, so it is called without holding the lock
+	for _, id := range ids {
+		snapshot, err := s.GetCategorySnapshot(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err := fn(*snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) GetProductSnapshot(_ context.Context, categoryID, id int64) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	product, ok := s.products[productKey{categoryID, id}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &Snapshot{
+		Product:  proto.Clone(product).(*pb.Product),
+		Sequence: s.productSequences[id],
+	}, nil
+}
+
+func (s *MemoryStore) ScanProductSnapshots(ctx context.Context, categoryID int64, fn func(Snapshot) error) error {
+	s.mu.RLock()
+	keys := make([]productKey, 0, len(s.products))
+	for key := range s.products {
+		if categoryID == 0 || key.categoryID == categoryID {
+			keys = append(keys, key)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, key := range keys {
+		snapshot, err := s.GetProductSnapshot(ctx, key.categoryID, key.id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err := fn(*snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) InsertOutboxEvent(_ context.Context, event OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.putOutboxEvent(event)
+	return nil
+}
+
+// ListOutboxEvents returns the events of a bucket ordered by id ascending.
+func (s *MemoryStore) ListOutboxEvents(_ context.Context, bucket string) ([]OutboxEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	events := make([]OutboxEvent, 0, len(s.outbox[bucket]))
+	for _, event := range s.outbox[bucket] {
+		events = append(events, event)
+	}
+
+	sort.Slice(events, func(i, j int) bool { return compareUUID(events[i].Id, events[j].Id) < 0 })
+	return events, nil
+}
+
+func (s *MemoryStore) DeleteOutboxEvent(_ context.Context, bucket string, id gocql.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deleteOutboxEvent(bucket, id)
+	return nil
+}
+
+func (s *MemoryStore) AssignPublishSequence(_ context.Context, event OutboxEvent, topic string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if stored, ok := s.outbox[event.Bucket][event.Id]; ok {
+		stored.PublishSequence = event.PublishSequence
+		s.outbox[event.Bucket][event.Id] = stored
+	}
+	s.reserved[topic] = event.PublishSequence
+	return nil
+}
+
+func (s *MemoryStore) GetReservedSequence(_ context.Context, topic string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.reserved[topic], nil
+}
+
+func (s *MemoryStore) RecordOutboxFailure(_ context.Context, event OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.outbox[event.Bucket][event.Id]; ok {
+		s.outbox[event.Bucket][event.Id] = event
+	}
+	return nil
+}
+
+func (s *MemoryStore) DeadLetterOutboxEvent(_ context.Context, event OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.NextAttemptAt = time.Time{}
+	event.PublishSequence = 0
+	s.dlq[event.Id] = DeadLetter{OutboxEvent: event, DeadLetteredAt: time.Now()}
+	s.deleteOutboxEvent(event.Bucket, event.Id)
+	return nil
+}
+
+// ListDeadLetters pages through dead letters ordered by id; the page state
+// is the id of the last dead letter of the previous page.
+func (s *MemoryStore) ListDeadLetters(_ context.Context, pageSize int, pageState []byte) ([]DeadLetter, []byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deadLetters := make([]DeadLetter, 0, len(s.dlq))
+	for _, deadLetter := range s.dlq {
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	sort.Slice(deadLetters, func(i, j int) bool {
+		return bytes.Compare(deadLetters[i].Id[:], deadLetters[j].Id[:]) < 0
+	})
+
+	start := 0
+	if len(pageState) > 0 {
+		start = sort.Search(len(deadLetters), func(i int) bool {
+			return bytes.Compare(deadLetters[i].Id[:], pageState) > 0
+		})
+	}
+	deadLetters = deadLetters[start:]
+
+	if pageSize <= 0 || len(deadLetters) <= pageSize {
+		return deadLetters, nil, nil
+	}
+
+	last := deadLetters[pageSize-1].Id
+	return deadLetters[:pageSize], last[:], nil
+}
+
+func (s *MemoryStore) GetDeadLetter(_ context.Context, id gocql.UUID) (*DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	deadLetter, ok := s.dlq[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &deadLetter, nil
+}
+
+func (s *MemoryStore) RequeueDeadLetter(_ context.Context, id gocql.UUID, bucket string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	deadLetter, ok := s.dlq[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	s.putOutboxEvent(OutboxEvent{
+		Id:           deadLetter.Id,
+		Bucket:       bucket,
+		EventType:    deadLetter.EventType,
+		Payload:      deadLetter.Payload,
+		Data:         deadLetter.Data,
+		TraceContext: deadLetter.TraceContext,
+	})
+	delete(s.dlq, id)
+	return nil
+}
+
+func (s *MemoryStore) DiscardDeadLetter(_ context.Context, id gocql.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.dlq[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.dlq, id)
+	return nil
+}
+
+func (s *MemoryStore) GetOutboxCheckpoint(_ context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.checkpoint, nil
+}
+
+func (s *MemoryStore) SaveOutboxCheckpoint(_ context.Context, checkpoint string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoint = checkpoint
+	return nil
+}
+
+func (s *MemoryStore) ClaimOutboxShards(_ context.Context, window string, shards int) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.windows[window]; ok {
+		return current, nil
+	}
+	s.windows[window] = shards
+	return shards, nil
+}
+
+func (s *MemoryStore) GetOutboxShards(_ context.Context, window string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.windows[window], nil
+}
+
+func (s *MemoryStore) TryAcquireLease(_ context.Context, name, holder string, ttl time.Duration) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if current, ok := s.leases[name]; ok && now.Before(current.expires) {
+		return current.holder, false, nil
+	}
+
+	s.leases[name] = lease{holder: holder, expires: now.Add(ttl)}
+	return holder, true, nil
+}
+
+func (s *MemoryStore) RenewLease(_ context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	current, ok := s.leases[name]
+	if !ok || current.holder != holder || !now.Before(current.expires) {
+		return false, nil
+	}
+
+	s.leases[name] = lease{holder: holder, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (s *MemoryStore) ReleaseLease(_ context.Context, name, holder string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.leases[name]; ok && current.holder == holder {
+		delete(s.leases, name)
+	}
+	return nil
+}
+
+func (s *MemoryStore) ProjectionEventApplied(_ context.Context, eventID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.appliedEvents[eventID]
+	return ok, nil
+}
+
+func (s *MemoryStore) GetProductView(_ context.Context, id int64) (*ProductView, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	view, ok := s.productViews[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	view.Product = proto.Clone(view.Product).(*pb.Product)
+	return &view, nil
+}
+
+// PutProductView stores the view; the memory store keeps a single view per
+// product, so previous only matters for the category membership.
+func (s *MemoryStore) PutProductView(_ context.Context, view ProductView, previous *ProductView, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	view.Product = proto.Clone(view.Product).(*pb.Product)
+	s.productViews[view.Product.Id] = view
+
+	if previous != nil {
+		delete(s.categoryProducts[previous.Product.CategoryId], previous.Product.Id)
+	}
+	if s.categoryProducts[view.Product.CategoryId] == nil {
+		s.categoryProducts[view.Product.CategoryId] = make(map[int64]struct{})
+	}
+	s.categoryProducts[view.Product.CategoryId][view.Product.Id] = struct{}{}
+	s.appliedEvents[eventID] = struct{}{}
+	return nil
+}
+
+func (s *MemoryStore) DeleteProductView(_ context.Context, view ProductView, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.productViews, view.Product.Id)
+	delete(s.categoryProducts[view.Product.CategoryId], view.Product.Id)
+	s.appliedEvents[eventID] = struct{}{}
+	return nil
+}
+
+func (s *MemoryStore) CountCategoryProducts(_ context.Context, categoryID int64) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.categoryProducts[categoryID])), nil
+}
+
+func (s *MemoryStore) DeleteCategoryProducts(_ context.Context, categoryID int64, eventID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.categoryProducts, categoryID)
+	s.appliedEvents[eventID] = struct{}{}
+	return nil
+}
+
+func (s *MemoryStore) ResetProjection(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.productViews = make(map[int64]ProductView)
+	s.categoryProducts = make(map[int64]map[int64]struct{})
+	s.appliedEvents = make(map[string]struct{})
+	return nil
+}
+
+func (s *MemoryStore) putOutboxEvent(event OutboxEvent) {
+	if s.outbox[event.Bucket] == nil {
+		s.outbox[event.Bucket] = make(map[gocql.UUID]OutboxEvent)
+	}
+	s.outbox[event.Bucket][event.Id] = event
+}
+
+func (s *MemoryStore) deleteOutboxEvent(bucket string, id gocql.UUID) {
+	delete(s.outbox[bucket], id)
+	if len(s.outbox[bucket]) == 0 {
+		delete(s.outbox, bucket)
+	}
+}
+
+// compareUUID orders time-based UUIDs by timestamp the way Cassandra's
+// timeuuid comparator does, falling back to a byte comparison.
+func compareUUID(a, b gocql.UUID) int {
+	if a.Version() == 1 && b.Version() == 1 {
+		if ta, tb := a.Time(), b.Time(); !ta.Equal(tb) {
+			if ta.Before(tb) {
+				return -1
+			}
+			return 1
+		}
+	}
+	return bytes.Compare(a[:], b[:])
+}
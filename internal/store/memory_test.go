@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const testLease = "outbox-relay"
+
+func TestMemoryStoreLeases(t *testing.T) {
+	tests := []struct {
+		name string
+		// prepare runs against the store before the lease is acquired
+		prepare func(t *testing.T, s *MemoryStore)
+		holder  string
+
+		wantHolder   string
+		wantAcquired bool
+	}{
+		{
+			name:         "free lease",
+			prepare:      func(*testing.T, *MemoryStore) {},
+			holder:       "self",
+			wantHolder:   "self",
+			wantAcquired: true,
+		},
+		{
+			name: "lease held by another holder",
+			prepare: func(t *testing.T, s *MemoryStore) {
+				acquireLease(t, s, "other", time.Minute)
+			},
+			holder:     "self",
+			wantHolder: "other",
+		},
+		{
+			name: "own lease still live",
+			prepare: func(t *testing.T, s *MemoryStore) {
+				acquireLease(t, s, "self", time.Minute)
+			},
+			holder:     "self",
+			wantHolder: "self",
+		},
+		{
+			name: "expired lease",
+			prepare: func(t *testing.T, s *MemoryStore) {
+				acquireLease(t, s, "other", time.Millisecond)
+				time.Sleep(5 * time.Millisecond)
+			},
+			holder:       "self",
+			wantHolder:   "self",
+			wantAcquired: true,
+		},
+		{
+			name: "released lease",
+			prepare: func(t *testing.T, s *MemoryStore) {
+				acquireLease(t, s, "other", time.Minute)
+				if err := s.ReleaseLease(context.Background(), testLease, "other"); err != nil {
+					t.Fatalf("failed to release lease: %v", err)
+				}
+			},
+			holder:       "self",
+			wantHolder:   "self",
+			wantAcquired: true,
+		},
+		{
+			name: "release by another holder is ignored",
+			prepare: func(t *testing.T, s *MemoryStore) {
+				acquireLease(t, s, "other", time.Minute)
+				if err := s.ReleaseLease(context.Background(), testLease, "self"); err != nil {
+					t.Fatalf("failed to release lease: %v", err)
+				}
+			},
+			holder:     "self",
+			wantHolder: "other",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore()
+			tt.prepare(t, s)
+
+			holder, acquired, err := s.TryAcquireLease(context.Background(), testLease, tt.holder, time.Minute)
+			if err != nil {
+				t.Fatalf("TryAcquireLease() error = %v", err)
+			}
+			if holder != tt.wantHolder || acquired != tt.wantAcquired {
+				t.Errorf("TryAcquireLease() = %q, %v, want %q, %v", holder, acquired, tt.wantHolder, tt.wantAcquired)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreRenewLease(t *testing.T) {
+	tests := []struct {
+		name   string
+		holder string
+		ttl    time.Duration // of the lease acquired by "self"
+		wait   time.Duration
+
+		wantRenewed bool
+	}{
+		{name: "holder renews", holder: "self", ttl: time.Minute, wantRenewed: true},
+		{name: "other holder cannot renew", holder: "other", ttl: time.Minute},
+		{name: "expired lease is not renewed", holder: "self", ttl: time.Millisecond, wait: 5 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore()
+			acquireLease(t, s, "self", tt.ttl)
+			time.Sleep(tt.wait)
+
+			renewed, err := s.RenewLease(context.Background(), testLease, tt.holder, time.Minute)
+			if err != nil {
+				t.Fatalf("RenewLease() error = %v", err)
+			}
+			if renewed != tt.wantRenewed {
+				t.Errorf("RenewLease() = %v, want %v", renewed, tt.wantRenewed)
+			}
+		})
+	}
+}
+
+func acquireLease(t *testing.T, s *MemoryStore, holder string, ttl time.Duration) {
+	t.Helper()
+
+	if _, acquired, err := s.TryAcquireLease(context.Background(), testLease, holder, ttl); err != nil || !acquired {
+		t.Fatalf("failed to acquire lease: acquired = %v, error = %v", acquired, err)
+	}
+}
+
+func TestMemoryStoreClaimOutboxShards(t *testing.T) {
+	const (
+		window = "2026-10-19T12"
+		next   = "2026-10-19T13"
+	)
+	ctx := context.Background()
+	s := NewMemoryStore()
+
+	if shards, err := s.GetOutboxShards(ctx, window); err != nil || shards != 0 {
+		t.Fatalf("GetOutboxShards() of an unclaimed window = %d, %v, want 0", shards, err)
+	}
+
+	claims := []struct {
+		window     string
+		shards     int
+		wantShards int
+	}{
+		{window: window, shards: 8, wantShards: 8},
+		// the first claim of a window wins
+		{window: window, shards: 4, wantShards: 8},
+		{window: next, shards: 4, wantShards: 4},
+	}
+	for _, claim := range claims {
+		shards, err := s.ClaimOutboxShards(ctx, claim.window, claim.shards)
+		if err != nil {
+			t.Fatalf("ClaimOutboxShards() error = %v", err)
+		}
+		if shards != claim.wantShards {
+			t.Errorf("ClaimOutboxShards(%s, %d) = %d, want %d", claim.window, claim.shards, shards, claim.wantShards)
+		}
+	}
+
+	if shards, err := s.GetOutboxShards(ctx, window); err != nil || shards != 8 {
+		t.Errorf("GetOutboxShards() = %d, %v, want 8", shards, err)
+	}
+}
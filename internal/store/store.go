@@ -0,0 +1,215 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/yaninyzwitty/grpc-products-service/pb"
+)
+
+// ErrNotFound is returned when a requested row does not exist.
+var ErrNotFound = errors.New("not found")
+
+// OutboxEvent is a single row of the products outbox. Data holds a binary
+// pb.ProductEvent; Payload is only set on JSON rows written by older builds.
+// Sequence mirrors the event's sequence and is stored with its product or
+// category so later changes continue the stream. PublishSequence is the
+// producer sequence id reserved for the event, zero until one is assigned.
+// TraceContext holds the W3C traceparent and tracestate of the request that
+// wrote the event, so its publication continues the same trace.
+type OutboxEvent struct {
+	Id              gocql.UUID
+	Bucket          string
+	EventType       string
+	Payload         string
+	Data            []byte
+	Sequence        uint64
+	PublishSequence int64
+	Attempts        int
+	LastError       string
+	NextAttemptAt   time.Time
+	TraceContext    map[string]string
+}
+
+// DeadLetter is an outbox event that exceeded its retry budget.
+type DeadLetter struct {
+	OutboxEvent
+	DeadLetteredAt time.Time
+}
+
+// ProductStore persists products together with the outbox event describing the change.
+type ProductStore interface {
+	CreateProduct(ctx context.Context, product *pb.Product, event OutboxEvent) error
+}
+
+// CategoryStore persists categories together with the outbox event describing the change.
+type CategoryStore interface {
+	CreateCategory(ctx context.Context, category *pb.Category, event OutboxEvent) error
+}
+
+// OutboxStore reads and acknowledges pending outbox events.
+type OutboxStore interface {
+	ListOutboxEvents(ctx context.Context, bucket string) ([]OutboxEvent, error)
+	DeleteOutboxEvent(ctx context.Context, bucket string, id gocql.UUID) error
+	// GetOutboxCheckpoint returns the oldest window, or legacy daily bucket,
+	// that may still hold pending events, or an empty string if no
+	// checkpoint was saved yet.
+	GetOutboxCheckpoint(ctx context.Context) (string, error)
+	SaveOutboxCheckpoint(ctx context.Context, checkpoint string) error
+	// AssignPublishSequence stores the producer sequence id reserved for the
+	// event, so a replay after a crash is published with the same id, and
+	// raises the topic's reservation mark to it in the same write.
+	AssignPublishSequence(ctx context.Context, event OutboxEvent, topic string) error
+	// GetReservedSequence returns the highest sequence id ever reserved on
+	// the topic, zero when none was. Fresh ids are taken above it so they
+	// never collide with an id still stored on a pending event.
+	GetReservedSequence(ctx context.Context, topic string) (int64, error)
+	// RecordOutboxFailure stores the attempt count, last error, next attempt
+	// time and publish sequence of an event that failed to publish.
+	RecordOutboxFailure(ctx context.Context, event OutboxEvent) error
+	// DeadLetterOutboxEvent moves the event from the outbox to the dead-letter table.
+	DeadLetterOutboxEvent(ctx context.Context, event OutboxEvent) error
+	// ClaimOutboxShards sets the shard count of a window unless it was set
+	// before and returns the count the window uses.
+	ClaimOutboxShards(ctx context.Context, window string, shards int) (int, error)
+	// GetOutboxShards returns the shard count of a window, zero when nothing
+	// was written to it.
+	GetOutboxShards(ctx context.Context, window string) (int, error)
+}
+
+// DeadLetterStore manages events that were moved out of the outbox.
+type DeadLetterStore interface {
+	// ListDeadLetters returns up to pageSize dead letters starting at
+	// pageState, along with the state of the next page if there is one.
+	ListDeadLetters(ctx context.Context, pageSize int, pageState []byte) ([]DeadLetter, []byte, error)
+	GetDeadLetter(ctx context.Context, id gocql.UUID) (*DeadLetter, error)
+	// RequeueDeadLetter moves the event back into the given outbox bucket
+	// with a fresh retry budget.
+	RequeueDeadLetter(ctx context.Context, id gocql.UUID, bucket string) error
+	DiscardDeadLetter(ctx context.Context, id gocql.UUID) error
+}
+
+// LeaseStore grants time-bound exclusive leases used for leader election.
+type LeaseStore interface {
+	// TryAcquireLease takes the lease if nobody holds it and returns the
+	// current holder and whether this call acquired it. The holder may equal
+	// holder without acquiring, when its own earlier lease is still live.
+	TryAcquireLease(ctx context.Context, name, holder string, ttl time.Duration) (string, bool, error)
+	// RenewLease extends the lease and reports whether holder still owns it.
+	RenewLease(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	ReleaseLease(ctx context.Context, name, holder string) error
+}
+
+// ProductView is a product as materialized by the projection, along with
+// the sequence of the last event applied to it.
+type ProductView struct {
+	Product  *pb.Product
+	Sequence uint64
+}
+
+// ProjectionStore maintains the read models built from product events:
+// products by id, products by price within a category and product counts
+// per category.
+type ProjectionStore interface {
+	// ProjectionEventApplied reports whether the event was recorded as
+	// applied, so redelivered events are skipped. The writes below record
+	// their event together with the read models they change.
+	ProjectionEventApplied(ctx context.Context, eventID string) (bool, error)
+	GetProductView(ctx context.Context, id int64) (*ProductView, error)
+	// PutProductView upserts the view and counts the product in its
+	// category, replacing the rows of previous when the price or category
+	// changed, and records the event as applied.
+	PutProductView(ctx context.Context, view ProductView, previous *ProductView, eventID string) error
+	// DeleteProductView removes the view and the product from its
+	// category's count, and records the event as applied.
+	DeleteProductView(ctx context.Context, view ProductView, eventID string) error
+	CountCategoryProducts(ctx context.Context, categoryID int64) (int64, error)
+	// DeleteCategoryProducts clears the count of the category and records
+	// the event as applied.
+	DeleteCategoryProducts(ctx context.Context, categoryID int64, eventID string) error
+	// ResetProjection clears every read model and applied event.
+	ResetProjection(ctx context.Context) error
+}
+
+// Snapshot is the current state of a category or product, exactly one of
+// which is set, with the sequence of the last event written for it.
+type Snapshot struct {
+	Category *pb.Category
+	Product  *pb.Product
+	Sequence uint64
+}
+
+// ReplayStore reads the current state of categories and products so their
+// events can be regenerated.
+type ReplayStore interface {
+	GetCategorySnapshot(ctx context.Context, id int64) (*Snapshot, error)
+	GetProductSnapshot(ctx context.Context, categoryID, id int64) (*Snapshot, error)
+	// ScanCategorySnapshots calls fn for every category.
+	ScanCategorySnapshots(ctx context.Context, fn func(Snapshot) error) error
+	// ScanProductSnapshots calls fn for every product of the category, or of
+	// every category when categoryID is zero.
+	ScanProductSnapshots(ctx context.Context, categoryID int64, fn func(Snapshot) error) error
+	// InsertOutboxEvent writes an event that comes without a change, such
+	// as a replayed one.
+	InsertOutboxEvent(ctx context.Context, event OutboxEvent) error
+}
+
+// Store groups every repository the service depends on.
+type Store interface {
+	ProductStore
+	CategoryStore
+	OutboxStore
+	DeadLetterStore
+	LeaseStore
+	ProjectionStore
+	ReplayStore
+}
+
+// The outbox is split into hourly windows of one or more shards, each shard
+// stored in its own bucket partition so writes are spread over the cluster.
+const (
+	// WindowLayout is the time layout of outbox window names.
+	WindowLayout = "2006-01-02T15"
+	// Window is the span of time covered by an outbox window.
+	Window = time.Hour
+
+	// LegacyBucketLayout is the time layout of the daily buckets written
+	// before the outbox was sharded.
+	LegacyBucketLayout = "2006-01-02"
+)
+
+// WindowFor returns the outbox window an event written at t belongs to.
+// Windows are named in UTC so no hour repeats or is skipped.
+func WindowFor(t time.Time) string {
+	return t.UTC().Format(WindowLayout)
+}
+
+// ParseWindow returns the start of the hour the window covers.
+func ParseWindow(window string) (time.Time, error) {
+	return time.Parse(WindowLayout, window)
+}
+
+// BucketFor returns the bucket of a shard of the window.
+func BucketFor(window string, shard int) string {
+	return fmt.Sprintf("%s#%d", window, shard)
+}
+
+// ShardFor spreads subjects over the shards of a window; all events of a
+// subject within a window share a shard, keeping them in outbox order.
+func ShardFor(subject string, shards int) int {
+	if shards <= 1 {
+		return 0
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(subject))
+	return int(hash.Sum32() % uint32(shards))
+}
+
+// ParseLegacyBucket returns the start of the day a legacy daily bucket covers.
+func ParseLegacyBucket(bucket string) (time.Time, error) {
+	return time.ParseInLocation(LegacyBucketLayout, bucket, time.Local)
+}
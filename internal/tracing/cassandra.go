@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// CassandraObserver records a client span for every query and batch attempt
+// made within a trace. Queries outside of one, such as the relay polling the
+// outbox, are not traced so they do not start a trace of their own.
+type CassandraObserver struct{}
+
+func (CassandraObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	observeSpan(ctx, spanName(q.Statement), q.Host, q.Start, q.End, q.Err,
+		semconv.DBQueryText(q.Statement),
+		attribute.String("db.cassandra.keyspace", q.Keyspace),
+		attribute.Int("db.cassandra.attempt", q.Attempt),
+		attribute.Int("db.cassandra.rows", q.Rows),
+	)
+}
+
+func (CassandraObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return
+	}
+
+	observeSpan(ctx, "BATCH", b.Host, b.Start, b.End, b.Err,
+		semconv.DBQueryText(strings.Join(b.Statements, ";\n")),
+		attribute.String("db.cassandra.keyspace", b.Keyspace),
+		attribute.Int("db.cassandra.attempt", b.Attempt),
+		attribute.Int("db.operation.batch.size", len(b.Statements)),
+	)
+}
+
+// observeSpan records an attempt that already finished, backdating the span
+// to when the attempt started.
+func observeSpan(ctx context.Context, name string, host *gocql.HostInfo, start, end time.Time, err error, attrs ...attribute.KeyValue) {
+	attrs = append(attrs, semconv.DBSystemCassandra)
+	if host != nil {
+		attrs = append(attrs, semconv.ServerAddress(host.ConnectAddress().String()), semconv.ServerPort(host.Port()))
+	}
+
+	_, span := Tracer().Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(attrs...),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End(trace.WithTimestamp(end))
+}
+
+// spanName is the verb of the statement, e.g. SELECT, the full statement is
+// recorded on the span.
+func spanName(statement string) string {
+	if fields := strings.Fields(statement); len(fields) > 0 {
+		return strings.ToUpper(fields[0])
+	}
+	return "CQL"
+}
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/outbox"
+)
+
+// HealthHandler serves the worker's health endpoints:
+//
+//	/healthz  liveness, always 200 while the process serves requests
+//	/readyz   200 unless the relay circuit breaker is open, 503 otherwise
+//	/status   the worker Status as JSON
+func (w *RelayWorker) HealthHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /healthz", func(rw http.ResponseWriter, _ *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte("ok\n"))
+	})
+
+	mux.HandleFunc("GET /readyz", func(rw http.ResponseWriter, _ *http.Request) {
+		status := w.Status()
+		if status.Breaker == outbox.BreakerOpen.String() {
+			http.Error(rw, "relay circuit breaker open: "+status.LastError, http.StatusServiceUnavailable)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte("ok\n"))
+	})
+
+	mux.HandleFunc("GET /status", func(rw http.ResponseWriter, _ *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(rw).Encode(w.Status())
+	})
+
+	return mux
+}
@@ -0,0 +1,95 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/projection"
+	"github.com/yaninyzwitty/grpc-products-service/internal/queue"
+	"github.com/yaninyzwitty/grpc-products-service/internal/secrets"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+// ProjectionWorker wires the projection consumer with its Pulsar
+// subscription on every topic the relay publishes to.
+type ProjectionWorker struct {
+	client   pulsar.Client
+	consumer pulsar.Consumer
+	runner   *projection.Consumer
+}
+
+// NewProjectionWorker subscribes to the event topics. With rebuild the
+// subscription is dropped and the read models are cleared first, so every
+// retained event is applied again; no other projection consumer may be
+// connected while rebuilding.
+func NewProjectionWorker(ctx context.Context, cfg *pkg.Config, secretCache *secrets.Cache, projectionStore store.ProjectionStore, rebuild bool) (*ProjectionWorker, error) {
+	if cfg.Queue.SubscriptionName == "" {
+		return nil, errors.New("the projection requires a pulsar subscription name")
+	}
+
+	pulsarClient := queue.NewPulsar(&queue.PulsarConfig{
+		URI:       cfg.Queue.Uri,
+		Token:     secretCache.Supplier(secrets.PulsarToken),
+		TopicName: cfg.Queue.TopicName,
+		Routes:    cfg.Queue.Routes,
+
+		SubscriptionName: cfg.Queue.SubscriptionName,
+	})
+
+	client, err := pulsarClient.CreatePulsarConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	consumer, err := pulsarClient.CreatePulsarConsumer(ctx, client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	if rebuild {
+		// Unsubscribing fails while other consumers are connected, which
+		// keeps the read models intact
+		if err := consumer.Unsubscribe(); err != nil {
+			consumer.Close()
+			client.Close()
+			return nil, fmt.Errorf("failed to drop the projection subscription: %w", err)
+		}
+		consumer.Close()
+
+		if err := projectionStore.ResetProjection(ctx); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("failed to reset the projection: %w", err)
+		}
+		slog.Info("projection reset, replaying events from the earliest retained message")
+
+		consumer, err = pulsarClient.CreatePulsarConsumer(ctx, client)
+		if err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	runner := projection.NewConsumer(consumer, projectionStore)
+
+	return &ProjectionWorker{
+		client:   client,
+		consumer: consumer,
+		runner:   runner,
+	}, nil
+}
+
+// Run applies events until ctx is done.
+func (w *ProjectionWorker) Run(ctx context.Context) error {
+	return w.runner.Run(ctx)
+}
+
+// Close closes the subscription and the client, call it once Run returned.
+func (w *ProjectionWorker) Close() {
+	w.consumer.Close()
+	w.client.Close()
+}
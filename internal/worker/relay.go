@@ -0,0 +1,172 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/election"
+	"github.com/yaninyzwitty/grpc-products-service/internal/events"
+	"github.com/yaninyzwitty/grpc-products-service/internal/outbox"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/publisher"
+	"github.com/yaninyzwitty/grpc-products-service/internal/queue"
+	"github.com/yaninyzwitty/grpc-products-service/internal/secrets"
+	"github.com/yaninyzwitty/grpc-products-service/internal/store"
+)
+
+// LeaseName is the lease every relay worker, embedded or standalone, competes for.
+const LeaseName = "products-outbox-relay"
+
+// RelayWorker wires the outbox relay with its publisher, leader election and
+// supervisor, so the gRPC server and the standalone relay run it the same way.
+type RelayWorker struct {
+	publisher  publisher.Publisher
+	relay      *outbox.Relay
+	elector    *election.Elector
+	supervisor *outbox.Supervisor
+}
+
+// Status is a snapshot of the worker's state.
+type Status struct {
+	Leader              bool   `json:"leader"`
+	Breaker             string `json:"breaker"`
+	Failures            int    `json:"failures"`
+	LastError           string `json:"last_error,omitempty"`
+	OldestPendingBucket string `json:"oldest_pending_bucket,omitempty"`
+}
+
+// NewRelayWorker builds the publisher selected by the config and the relay
+// draining the outbox store into it. A nil health reporter disables gRPC
+// health reporting.
+func NewRelayWorker(ctx context.Context, cfg *pkg.Config, secretCache *secrets.Cache, outboxStore store.Store, health outbox.HealthReporter) (*RelayWorker, error) {
+	eventsCfg := events.Config{
+		Source: cfg.Queue.CloudEvents.Source,
+		Mode:   events.Mode(cfg.Queue.CloudEvents.Mode),
+		Format: events.Format(cfg.Queue.CloudEvents.Format),
+	}
+
+	producerSchema, err := events.ProducerSchema(&eventsCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pulsarCfg := &queue.PulsarConfig{
+		URI:       cfg.Queue.Uri,
+		AdminURL:  cfg.Queue.AdminURL,
+		Token:     secretCache.Supplier(secrets.PulsarToken),
+		TopicName: cfg.Queue.TopicName,
+		Routes:    cfg.Queue.Routes,
+		Schema:    producerSchema,
+
+		ProducerName: cfg.Queue.ProducerName,
+		Transactions: cfg.Queue.Transactions,
+	}
+
+	// The Pulsar publisher fails fast when the deployed schema rejects what we emit.
+	publisherKind := publisher.Kind(cfg.Publisher.Kind)
+	eventPublisher, err := publisher.New(ctx, &publisher.Config{
+		Kind:   publisherKind,
+		Pulsar: pulsarCfg,
+		Kafka: publisher.KafkaConfig{
+			Brokers: cfg.Publisher.Kafka.Brokers,
+			Topic:   cfg.Publisher.Kafka.Topic,
+			Routes:  cfg.Publisher.Kafka.Routes,
+		},
+		NATS: publisher.NATSConfig{
+			URL:       cfg.Publisher.NATS.URL,
+			Subject:   cfg.Publisher.NATS.Subject,
+			Routes:    cfg.Publisher.NATS.Routes,
+			JetStream: cfg.Publisher.NATS.JetStream,
+		},
+		File: publisher.FileConfig{
+			Path: cfg.Publisher.File.Path,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usesPulsar := publisherKind == "" || publisherKind == publisher.KindPulsar
+	relay, err := outbox.NewRelay(&outbox.RelayConfig{
+		Lookback:        time.Duration(cfg.Outbox.LookbackDays) * 24 * time.Hour,
+		MaxAttempts:     cfg.Outbox.MaxAttempts,
+		RetryBackoff:    time.Duration(cfg.Outbox.RetryBackoff) * time.Second,
+		MaxRetryBackoff: time.Duration(cfg.Outbox.MaxBackoff) * time.Second,
+		CloudEvents:     eventsCfg,
+
+		Deduplication:      usesPulsar && cfg.Queue.ProducerName != "",
+		Transactions:       usesPulsar && cfg.Queue.Transactions,
+		TransactionTimeout: time.Duration(cfg.Queue.TransactionTimeout) * time.Second,
+
+		ReplayRate: float64(cfg.Outbox.ReplayRate),
+	}, outboxStore, eventPublisher)
+	if err != nil {
+		eventPublisher.Close()
+		return nil, err
+	}
+
+	// Only the replica holding the lease relays the outbox
+	elector := election.NewElector(&election.Config{
+		Name: LeaseName,
+		TTL:  time.Duration(cfg.Outbox.LeaseTTL) * time.Second,
+	}, outboxStore)
+
+	supervisor := outbox.NewSupervisor(&outbox.SupervisorConfig{
+		PollInterval:     time.Duration(cfg.Outbox.PollInterval) * time.Second,
+		FailureThreshold: cfg.Outbox.FailureThreshold,
+		BreakerCooldown:  time.Duration(cfg.Outbox.BreakerCooldown) * time.Second,
+	}, relay, elector, health)
+
+	return &RelayWorker{
+		publisher:  eventPublisher,
+		relay:      relay,
+		elector:    elector,
+		supervisor: supervisor,
+	}, nil
+}
+
+// Run relays the outbox until ctx is done and returns once the lease was released.
+func (w *RelayWorker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Go(func() { w.elector.Run(ctx) })
+	wg.Go(func() { w.supervisor.Run(ctx) })
+	wg.Wait()
+}
+
+// Reconfigure applies the live outbox settings of a reloaded config to the
+// running relay and supervisor.
+func (w *RelayWorker) Reconfigure(cfg *pkg.Config) {
+	w.relay.SetRetryPolicy(
+		cfg.Outbox.MaxAttempts,
+		time.Duration(cfg.Outbox.RetryBackoff)*time.Second,
+		time.Duration(cfg.Outbox.MaxBackoff)*time.Second,
+	)
+	w.relay.SetReplayRate(float64(cfg.Outbox.ReplayRate))
+	w.supervisor.Update(&outbox.SupervisorConfig{
+		PollInterval:     time.Duration(cfg.Outbox.PollInterval) * time.Second,
+		FailureThreshold: cfg.Outbox.FailureThreshold,
+		BreakerCooldown:  time.Duration(cfg.Outbox.BreakerCooldown) * time.Second,
+	})
+}
+
+// Status returns the worker's leadership, breaker and backlog state.
+func (w *RelayWorker) Status() Status {
+	state, failures, lastErr := w.supervisor.State()
+
+	status := Status{
+		Leader:              w.elector.IsLeader(),
+		Breaker:             state.String(),
+		Failures:            failures,
+		OldestPendingBucket: w.relay.OldestPendingBucket(),
+	}
+	if lastErr != nil {
+		status.LastError = lastErr.Error()
+	}
+	return status
+}
+
+// Close flushes and closes the publisher, call it once Run returned.
+func (w *RelayWorker) Close() error {
+	return w.publisher.Close()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/yaninyzwitty/grpc-products-service/internal/secrets"
+)
+
+// runSecretsSeal implements `secrets seal`: it encrypts a YAML map of secret
+// names to values read from stdin with the key in PRODUCTS_SECRETS_KEY, for
+// the encrypted secrets provider, returning the exit code.
+func runSecretsSeal(args []string) int {
+	fs := flag.NewFlagSet("secrets seal", flag.ExitOnError)
+	out := fs.String("out", "secrets.enc", "file to write the sealed secrets to")
+	_ = fs.Parse(args)
+
+	plaintext, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read secrets:", err)
+		return 1
+	}
+
+	sealed, err := secrets.Seal(os.Getenv(secrets.KeyEnv), plaintext)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to seal secrets:", err)
+		return 1
+	}
+
+	if err := os.WriteFile(*out, sealed, 0o600); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write sealed secrets:", err)
+		return 1
+	}
+
+	fmt.Println("secrets sealed to", *out)
+	return 0
+}
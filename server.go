@@ -2,21 +2,26 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
-	"github.com/joho/godotenv"
 	"github.com/yaninyzwitty/grpc-products-service/helpers"
 	"github.com/yaninyzwitty/grpc-products-service/internal/controllers"
-	"github.com/yaninyzwitty/grpc-products-service/internal/database"
-	"github.com/yaninyzwitty/grpc-products-service/internal/queue"
+	"github.com/yaninyzwitty/grpc-products-service/internal/logging"
+	"github.com/yaninyzwitty/grpc-products-service/internal/metrics"
+	"github.com/yaninyzwitty/grpc-products-service/internal/outbox"
+	"github.com/yaninyzwitty/grpc-products-service/internal/pkg"
+	"github.com/yaninyzwitty/grpc-products-service/internal/worker"
 	"github.com/yaninyzwitty/grpc-products-service/pb"
 	"github.com/yaninyzwitty/grpc-products-service/snowflake"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 
 	"google.golang.org/grpc"
 	health "google.golang.org/grpc/health"
@@ -25,24 +30,28 @@ import (
 )
 
 func main() {
-	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelInfo,
-	})
-	logger := slog.New(logHandler)
-	slog.SetDefault(logger)
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "check" {
+		os.Exit(runConfigCheck(os.Args[3:]))
+	}
+	if len(os.Args) > 2 && os.Args[1] == "secrets" && os.Args[2] == "seal" {
+		os.Exit(runSecretsSeal(os.Args[3:]))
+	}
+
+	var configOpts helpers.ConfigOptions
+	configOpts.RegisterFlags(flag.CommandLine)
+	flag.Parse()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	cfg, err := helpers.FetchFromAWSConfig(ctx)
+	process, err := helpers.Bootstrap(ctx, &configOpts, (*pkg.Config).Validate, "grpc-products-service")
 	if err != nil {
-		slog.Error("failed to load config from AWS", "error", err)
+		slog.Error("failed to start server", "error", err)
 		os.Exit(1)
 	}
-
-	if err := godotenv.Load(); err != nil {
-		slog.Error("failed to load environment variables", "error", err)
-	}
+	defer process.Close()
+	cfg := process.Config
+	productStore := process.Store
 
 	err = snowflake.InitSonyFlake()
 	if err != nil {
@@ -50,55 +59,31 @@ func main() {
 		os.Exit(1)
 	}
 
-	astraCfg := &database.AstraConfig{
-		Username: cfg.Database.Username,
-		Path:     cfg.Database.Path,
-		Token:    helpers.GetEnvOrDefault("ASTRA_TOKEN", ""),
-	}
-
-	db := database.NewAstraDB()
-	session, err := db.Connect(ctx, astraCfg, 30*time.Second)
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
 	if err != nil {
-		slog.Error("failed to connect to database", "error", err)
+		slog.Error("failed to listen", "error", err)
 		os.Exit(1)
 	}
-	defer session.Close()
 
-	pulsarCfg := &queue.PulsarConfig{
-		URI:       cfg.Queue.Uri,
-		Token:     helpers.GetEnvOrDefault("PULSAR_TOKEN", ""),
-		TopicName: cfg.Queue.TopicName,
-	}
-
-	pulsarClient, err := pulsarCfg.CreatePulsarConnection(ctx)
+	// The admin RPCs can discard events, they are only served on the admin address
+	adminLis, err := net.Listen("tcp", cfg.Server.AdminAddress)
 	if err != nil {
-		slog.Error("failed to create pulsar connection", "error", err)
+		slog.Error("failed to listen on the admin address", "error", err)
 		os.Exit(1)
 	}
-	defer pulsarClient.Close()
 
-	pulsarProducer, err := pulsarCfg.CreatePulsarProducer(ctx, pulsarClient)
-	if err != nil {
-		slog.Error("failed to create pulsar producer", "error", err)
-		os.Exit(1)
-	}
-	defer pulsarProducer.Close()
+	buckets := outbox.NewBucketAssigner(cfg.Outbox.Shards, productStore)
+	productController := controllers.NewProductController(productStore, productStore, buckets)
+	outboxAdminController := controllers.NewOutboxAdminController(productStore, buckets, outbox.NewReplayer(productStore, buckets))
 
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.Server.Port))
-	if err != nil {
-		slog.Error("failed to listen", "error", err)
-		os.Exit(1)
+	serverOpts := []grpc.ServerOption{
+		// Continues the trace of callers sending a traceparent and starts one otherwise
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor(), logging.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor(), logging.StreamServerInterceptor()),
 	}
-
-	productController := controllers.NewProductController(session)
-
-	server := grpc.NewServer()
-
-	// --- Register services ---
-	pb.RegisterProductsServiceServer(server, productController)
-
-	// Reflection for debugging
-	reflection.Register(server)
+	server := grpc.NewServer(serverOpts...)
+	adminServer := grpc.NewServer(serverOpts...)
 
 	// --- Health check service ---
 	healthServer := health.NewServer()
@@ -108,28 +93,41 @@ func main() {
 	healthServer.SetServingStatus("grpc-products-service", healthpb.HealthCheckResponse_SERVING)
 	healthpb.RegisterHealthServer(server, healthServer)
 
+	// The relay runs here unless it is deployed as the standalone relay command
+	var relayWorker *worker.RelayWorker
+	if !cfg.Server.DisableRelay {
+		relayWorker, err = worker.NewRelayWorker(ctx, cfg, process.Secrets, productStore, healthServer)
+		if err != nil {
+			slog.Error("failed to create outbox relay", "error", err)
+			os.Exit(1)
+		}
+		defer relayWorker.Close()
+	}
+
+	// --- Register services ---
+	pb.RegisterProductsServiceServer(server, productController)
+	pb.RegisterOutboxAdminServiceServer(adminServer, outboxAdminController)
+
+	// Reflection for debugging
+	reflection.Register(server)
+	reflection.Register(adminServer)
+
 	// --- Shutdown signals ---
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	stopCH := make(chan os.Signal, 1)
 
-	// Polling messages
-	go func() {
-		ticker := time.NewTicker(4 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				if err := helpers.ProcessMessages(context.Background(), session, pulsarProducer); err != nil {
-					slog.Error("failed to process messages", "error", err)
-					os.Exit(1)
-				}
-			case <-stopCH:
-				return
-			}
-		}
-	}()
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+
+	var relayWG sync.WaitGroup
+	if relayWorker != nil {
+		process.Reloader.OnReload(relayWorker.Reconfigure)
+		relayWG.Go(func() { relayWorker.Run(relayCtx) })
+	}
+
+	process.ServeMetrics(relayCtx)
+	// SIGHUP and new SSM parameter versions reload the live settings
+	process.Watch(relayCtx)
 
 	// Graceful shutdown
 	go func() {
@@ -138,13 +136,25 @@ func main() {
 		slog.Info("Shutting down gRPC server...")
 		healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING) // mark unhealthy
 		server.GracefulStop()
+		adminServer.GracefulStop()
+		stopRelay()
 		cancel()
 		slog.Info("gRPC server has been stopped gracefully")
 	}()
 
+	go func() {
+		slog.Info("Starting admin gRPC server", "address", cfg.Server.AdminAddress)
+		if err := adminServer.Serve(adminLis); err != nil {
+			slog.Error("admin gRPC server encountered an error while serving", "error", err)
+		}
+	}()
+
 	slog.Info("Starting gRPC server", "port", cfg.Server.Port)
 	if err := server.Serve(lis); err != nil {
 		slog.Error("gRPC server encountered an error while serving", "error", err)
 		os.Exit(1)
 	}
+
+	// Let the elector release its lease before exiting
+	relayWG.Wait()
 }